@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	newhtml "golang.org/x/net/html"
+)
+
+// A link to a #fragment on another page (or the same one), checked once every page has been rendered
+type anchorLink struct {
+	From string
+	Href string
+	Url  *url.URL
+}
+
+// The ids on every rendered page keyed by anchorKey(), and every link with a #fragment
+var siteAnchors = map[string]map[string]bool{}
+var siteAnchorLinks []anchorLink
+
+// Add a "#" permalink to the end of every heading that has an id, turned on with `headinganchors` in config.yaml
+func addHeadingAnchors(doc ast.Node) {
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		if id, ok := heading.AttributeString("id"); ok {
+			if idBytes, ok := id.([]byte); ok {
+				anchor := ast.NewString([]byte(` <a class="anchor" href="#` + newhtml.EscapeString(string(idBytes)) + `" aria-label="` + newhtml.EscapeString(translate("anchorlabel")) + `">#</a>`))
+				anchor.SetCode(true)
+				heading.AppendChild(heading, anchor)
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// Note the ids on a rendered page and the links on it that point at an id
+func recordAnchors(currentPage Page, pageHtml string) {
+	ids := map[string]bool{}
+	base, err := url.Parse(string(currentPage.Url))
+	if err != nil {
+		log.Print("ERROR: ", err, " Could not read the URL of ", currentPage.Path, " in recordAnchors(Page,string)")
+		return
+	}
+
+	tokenizer := newhtml.NewTokenizer(strings.NewReader(pageHtml))
+	for {
+		tt := tokenizer.Next()
+		if tt == newhtml.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				log.Print("ERROR: tokenizer: ", tokenizer.Err(), " in recordAnchors(Page,string)")
+			}
+			break
+		}
+		if tt != newhtml.StartTagToken && tt != newhtml.SelfClosingTagToken {
+			continue
+		}
+
+		tag, hasAttr := tokenizer.TagName()
+		for hasAttr {
+			var key, value []byte
+			key, value, hasAttr = tokenizer.TagAttr()
+
+			if string(key) == "id" {
+				ids[string(value)] = true
+			} else if string(key) == "href" && string(tag) == "a" && strings.Contains(string(value), "#") {
+				if ref, err := url.Parse(string(value)); err == nil {
+					siteAnchorLinks = append(siteAnchorLinks, anchorLink{From: currentPage.Path, Href: string(value), Url: base.ResolveReference(ref)})
+				}
+			}
+		}
+	}
+
+	siteAnchors[anchorKey(base)] = ids
+}
+
+// Pages are linked as /page, /page.html, /dir/ and /dir/index.html, they're all the same page
+func anchorKey(pageUrl *url.URL) string {
+	key := strings.TrimSuffix(pageUrl.Path, "/")
+	key = strings.TrimSuffix(key, "/index.html")
+	key = strings.TrimSuffix(key, ".html")
+	return pageUrl.Host + strings.TrimSuffix(key, "/")
+}
+
+// Warn about links (including redirects) to an id that isn't on the page anymore, usually because a heading was
+// reworded, give the heading a fixed id with {#id} to stop it happening again
+func checkAnchors() {
+	links := siteAnchorLinks
+	for _, currentRedirect := range siteRedirects.Redirect {
+		if to, err := url.Parse(siteMeta.BaseURL + currentRedirect.To); err == nil && strings.Contains(currentRedirect.To, "#") {
+			links = append(links, anchorLink{From: redirectFile + " (" + currentRedirect.From + ")", Href: currentRedirect.To, Url: to})
+		}
+	}
+
+	for _, link := range links {
+		ids, ok := siteAnchors[anchorKey(link.Url)]
+		if !ok || link.Url.Fragment == "" || ids[link.Url.Fragment] {
+			continue
+		}
+		log.Print("WARNING: ", link.From, " links to ", link.Href, " but there's no id `", link.Url.Fragment, "` on that page")
+	}
+}
+
+// Whether the tag the tokenizer is on is a link added by addHeadingAnchors (<a class="anchor">)
+func isHeadingAnchor(tokenizer *newhtml.Tokenizer) bool {
+	for {
+		key, value, more := tokenizer.TagAttr()
+		if string(key) == "class" && strings.Contains(" "+string(value)+" ", " anchor ") {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAnchorKey(t *testing.T) {
+	tests := []struct {
+		pageUrl string
+		want    string
+	}{
+		{"https://example.com/notes/alpha", "example.com/notes/alpha"},
+		{"https://example.com/notes/alpha.html", "example.com/notes/alpha"},
+		{"https://example.com/notes/alpha/", "example.com/notes/alpha"},
+		{"https://example.com/notes/alpha/index.html", "example.com/notes/alpha"},
+		{"https://example.com/", "example.com"},
+		{"/notes/alpha#install", "/notes/alpha"},
+	}
+
+	for _, test := range tests {
+		pageUrl, err := url.Parse(test.pageUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := anchorKey(pageUrl); got != test.want {
+			t.Errorf("anchorKey(%q) = %q, want %q", test.pageUrl, got, test.want)
+		}
+	}
+}
+
+func TestCheckAnchors(t *testing.T) {
+	savedMeta, savedRedirects := siteMeta, siteRedirects
+	defer func() {
+		siteMeta, siteRedirects = savedMeta, savedRedirects
+		siteAnchors, siteAnchorLinks = map[string]map[string]bool{}, nil
+		log.SetOutput(os.Stderr)
+	}()
+	siteMeta.BaseURL = "https://example.com"
+
+	tests := []struct {
+		name      string
+		html      string
+		redirects string
+		want      string
+	}{
+		{"same page", `<h2 id="install">Install</h2><a href="#install">up</a>`, "", ""},
+		{"other page", `<a href="/notes/beta#setup">setup</a>`, "", ""},
+		{"other page as .html", `<a href="/notes/beta.html#setup">setup</a>`, "", ""},
+		{"missing on the same page", `<a href="#gone">gone</a>`, "", "out/notes/alpha.html links to #gone but there's no id `gone`"},
+		{"missing on another page", `<a href="/notes/beta/#gone">gone</a>`, "", "links to /notes/beta/#gone but there's no id `gone`"},
+		{"page that isn't built", `<a href="/elsewhere#gone">gone</a>`, "", ""},
+		{"external page", `<a href="https://other.example/notes/beta#gone">gone</a>`, "", ""},
+		{"redirect", ``, "redirect:\n  - from: /old\n    to: /notes/beta#gone\n", "(/old) links to /notes/beta#gone but there's no id `gone`"},
+		{"redirect to an id", ``, "redirect:\n  - from: /old\n    to: /notes/beta#setup\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			siteAnchors, siteAnchorLinks = map[string]map[string]bool{}, nil
+			siteRedirects = Redirects{}
+			if err := yaml.Unmarshal([]byte(test.redirects), &siteRedirects); err != nil {
+				t.Fatal(err)
+			}
+
+			var logged bytes.Buffer
+			log.SetOutput(&logged)
+			recordAnchors(Page{Path: "out/notes/alpha.html", Url: "https://example.com/notes/alpha"}, test.html)
+			recordAnchors(Page{Path: "out/notes/beta.html", Url: "https://example.com/notes/beta"}, `<h2 id="setup">Setup</h2>`)
+			checkAnchors()
+
+			if test.want == "" && logged.Len() > 0 {
+				t.Errorf("checkAnchors() logged %q, want nothing", logged.String())
+			} else if !strings.Contains(logged.String(), test.want) {
+				t.Errorf("checkAnchors() logged %q, want %q", logged.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var authorsFile string = "./content/.config/authors.yaml"
+
+// An author from authors.yaml, or just a name when a page uses an author that isn't in it
+type Author struct {
+	ID     string
+	Name   string       `yaml:"name"`
+	Bio    string       `yaml:"bio"`
+	Avatar string       `yaml:"avatar"`
+	Email  string       `yaml:"email"`
+	Links  []AuthorLink `yaml:"links"`
+	Url    template.URL // the author's page, empty without an authors.yaml
+	Pages  []Page       // only filled in for .Site.Authors and author pages
+}
+
+// A link on an author's profile, e.g. their GitHub or Mastodon
+type AuthorLink struct {
+	Name string `yaml:"name"`
+	Url  string `yaml:"url"`
+}
+
+// Author profiles keyed by id
+var siteAuthors = map[string]Author{}
+
+// Read the optional authors.yaml, keyed by author id:
+//
+//	jane:
+//	  name: Jane Doe
+//	  bio: Runs the homelab
+//	  avatar: jane.jpg
+//	  links:
+//	    - name: GitHub
+//	      url: https://github.com/jane
+func loadAuthors() {
+	siteAuthors = map[string]Author{}
+
+	content, err := ioutil.ReadFile(authorsFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("FATAL: ", err, " Could not read ", authorsFile, " in loadAuthors()")
+	}
+
+	log.Println("Loading authors file.")
+	if err := yaml.Unmarshal(content, &siteAuthors); err != nil {
+		log.Fatal("FATAL: ", err, " Could not read ", authorsFile, " in loadAuthors()")
+	}
+
+	for id, author := range siteAuthors {
+		author.ID = id
+		if author.Name == "" {
+			author.Name = id
+		}
+		if author.Avatar != "" && !isAbsolute(author.Avatar) {
+			author.Avatar = mediaUrl(author.Avatar)
+		}
+		author.Url = template.URL(siteMeta.BaseURL + "/authors/" + slugify(id) + "/")
+		siteAuthors[id] = author
+	}
+}
+
+// The authors from a page's `author` or `authors` front matter, or the author from config.yaml
+func pageAuthors(frontMatter map[string]interface{}) []Author {
+	var names []string
+
+	switch v := frontMatter["authors"].(type) {
+	case []interface{}:
+		for _, name := range v {
+			names = append(names, fmt.Sprintf("%v", name))
+		}
+	case string:
+		names = strings.Split(v, ",")
+	}
+	if len(names) == 0 && metaString(frontMatter, "author") != "" {
+		names = []string{metaString(frontMatter, "author")}
+	}
+	if len(names) == 0 && siteMeta.Author != "" {
+		names = []string{siteMeta.Author}
+	}
+
+	var authors []Author
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			authors = append(authors, findAuthor(name))
+		}
+	}
+	return authors
+}
+
+// Look an author up by id or by name, authors that aren't in authors.yaml only have a name
+func findAuthor(name string) Author {
+	if author, ok := siteAuthors[name]; ok {
+		return author
+	}
+	for _, author := range siteAuthors {
+		if strings.EqualFold(author.Name, name) {
+			return author
+		}
+	}
+
+	author := Author{ID: slugify(name), Name: name}
+	if len(siteAuthors) > 0 {
+		author.Url = template.URL(siteMeta.BaseURL + "/authors/" + author.ID + "/")
+	}
+	return author
+}
+
+// The authors' names for the `author` meta tag, e.g. "Jane Doe, John Smith"
+func authorNames(authors []Author) string {
+	var names []string
+	for _, author := range authors {
+		names = append(names, author.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Every author of an article sorted by name, each with their articles newest first
+func collectAuthors(pages []Page) []Author {
+	var authors []Author
+	positions := map[string]int{}
+
+	for _, currentPage := range pages {
+		if currentPage.Kind != "article" {
+			continue
+		}
+		for _, author := range currentPage.Authors {
+			if _, ok := positions[author.ID]; !ok {
+				positions[author.ID] = len(authors)
+				authors = append(authors, author)
+			}
+			authors[positions[author.ID]].Pages = append(authors[positions[author.ID]].Pages, currentPage)
+		}
+	}
+
+	sort.SliceStable(authors, func(i, j int) bool {
+		return strings.ToLower(authors[i].Name) < strings.ToLower(authors[j].Name)
+	})
+	for _, author := range authors {
+		sortPages(author.Pages, "date")
+	}
+
+	return authors
+}
+
+// A paginated page for every author listing their articles, plus an /authors/ page listing the authors
+// Only built when the site has an authors.yaml
+func buildAuthorPages(pages []Page) []Page {
+	var authorPages []Page
+	authors := collectAuthors(pages)
+	if len(siteAuthors) == 0 || len(authors) == 0 {
+		return nil
+	}
+
+	authorsUrl := siteMeta.BaseURL + "/authors/"
+	var authorsHtml strings.Builder
+	authorsHtml.WriteString("<ul>\n")
+
+	for _, author := range authors {
+		authorsHtml.WriteString("<li><a href=\"" + string(author.Url) + "\">" + template.HTMLEscapeString(author.Name) + "</a> (" + strconv.Itoa(len(author.Pages)) + ")</li>\n")
+
+		crumbs := []Breadcrumb{
+			{Title: translate("home"), Url: template.URL(siteMeta.BaseURL)},
+			{Title: translate("authors"), Url: template.URL(authorsUrl)},
+			{Title: author.Name, Url: author.Url},
+		}
+		description := author.Bio
+		if description == "" {
+			description = translate("authordescription", author.Name)
+		}
+		ogImage := mediaUrl(siteMeta.OgImage)
+		if author.Avatar != "" {
+			ogImage = author.Avatar
+		}
+
+		authorPage := Page{
+			Title:       author.Name,
+			Kind:        "author",
+			Content:     authorProfile(author),
+			Path:        sitePaths.Output + "/authors/" + slugify(author.ID) + "/index.html",
+			SiteRoot:    template.URL(siteMeta.BaseURL),
+			Nav:         template.HTML(renderBreadcrumbs(crumbs)),
+			Breadcrumbs: crumbs,
+			Analytics:   siteMeta.Analytics,
+			Description: template.HTML(template.HTMLEscapeString(description)),
+			OgType:      "profile",
+			Url:         author.Url,
+			OgImage:     ogImage,
+			ChangeFreq:  "weekly",
+			Priority:    "0.5",
+			Authors:     []Author{author},
+			Author:      author.Name,
+		}
+
+		authorPages = append(authorPages, paginatedPages(authorPage, author.Pages, func(pager *Paginator) template.HTML {
+			var listingHtml strings.Builder
+			listingHtml.WriteString("<ul>\n")
+			for _, currentPage := range pager.Items {
+				listingHtml.WriteString("<li><a href=\"" + string(currentPage.Url) + "\">" + currentPage.Title + "</a>" + listingSummary(currentPage) + "</li>\n")
+			}
+			listingHtml.WriteString("</ul>\n")
+			return template.HTML(listingHtml.String())
+		})...)
+	}
+	authorsHtml.WriteString("</ul>\n")
+
+	crumbs := []Breadcrumb{
+		{Title: translate("home"), Url: template.URL(siteMeta.BaseURL)},
+		{Title: translate("authors"), Url: template.URL(authorsUrl)},
+	}
+	authorPages = append(authorPages, Page{
+		Title:       translate("authors"),
+		Kind:        "authors",
+		Content:     template.HTML(authorsHtml.String()),
+		Path:        sitePaths.Output + "/authors/index.html",
+		SiteRoot:    template.URL(siteMeta.BaseURL),
+		Nav:         template.HTML(renderBreadcrumbs(crumbs)),
+		Breadcrumbs: crumbs,
+		Analytics:   siteMeta.Analytics,
+		Description: template.HTML(translate("authorsdescription")),
+		OgType:      "website",
+		Url:         template.URL(authorsUrl),
+		OgImage:     mediaUrl(siteMeta.OgImage),
+		ChangeFreq:  "weekly",
+		Priority:    "0.5",
+	})
+
+	return authorPages
+}
+
+// The avatar, bio and links at the top of an author's page
+func authorProfile(author Author) template.HTML {
+	var profileHtml strings.Builder
+
+	profileHtml.WriteString("<div class=\"author\">\n")
+	if author.Avatar != "" {
+		profileHtml.WriteString("<img class=\"avatar\" src=\"" + template.HTMLEscapeString(author.Avatar) + "\" alt=\"" + template.HTMLEscapeString(author.Name) + "\" />\n")
+	}
+	if author.Bio != "" {
+		profileHtml.WriteString("<p>" + string(markdownify(author.Bio)) + "</p>\n")
+	}
+	if len(author.Links) > 0 {
+		profileHtml.WriteString("<ul class=\"links\">\n")
+		for _, link := range author.Links {
+			profileHtml.WriteString("<li><a href=\"" + template.HTMLEscapeString(link.Url) + "\" rel=\"me\">" + template.HTMLEscapeString(link.Name) + "</a></li>\n")
+		}
+		profileHtml.WriteString("</ul>\n")
+	}
+	profileHtml.WriteString("</div>\n")
+
+	return template.HTML(profileHtml.String())
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Front matter used by `new` when there's no archetype file
+const defaultArchetype = `---
+title:        {{quote .Title}}
+date:         "{{.Date}}"
+description:  ""
+tags:         ""
+---
+`
+
+// What an archetype template can use
+type Archetype struct {
+	Title    string
+	Slug     string
+	Date     string
+	Section  string
+	Category string
+}
+
+var archetypeDirectory string = "./content/.config/archetypes/"
+
+// Functions available in archetypes, quote makes a double quoted string that's safe to use as a YAML value
+var archetypeFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+}
+
+// Sample content and a starter theme for `init`
+//
+//go:embed all:starter
+var starterFiles embed.FS
+
+// Run a command instead of building the site, returns false if there isn't one
+// Anything else on the command line is ignored with a warning and the site is built like it always was
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "new":
+		if len(args) != 2 {
+			log.Fatal("FATAL: usage: gopubsite new <section>/<category>/<slug>")
+		}
+		newContent(args[1])
+	case "init":
+		if len(args) > 2 {
+			log.Fatal("FATAL: usage: gopubsite init [directory]")
+		}
+		siteDirectory := "."
+		if len(args) == 2 {
+			siteDirectory = args[1]
+		}
+		initSite(siteDirectory)
+	default:
+		log.Print("WARNING: unknown command `", args[0], "` (expected new or init), ignoring it and building the site")
+		return false
+	}
+	return true
+}
+
+// Create ./content/N_section/_category/slug.md from the section's archetype, creating the directories (./content too) if needed
+func newContent(contentPath string) {
+	parts := strings.Split(strings.Trim(contentPath, "/"), "/")
+	if len(parts) < 2 {
+		log.Fatal("FATAL: expected <section>/<category>/<slug> but got `", contentPath, "`")
+	}
+	slug := strings.TrimSuffix(parts[len(parts)-1], ".md")
+
+	// Titles come from displayName, which needs `acronyms` from the config
+	if _, err := os.Stat(configFile); err == nil {
+		loadSiteMeta()
+	}
+
+	createDirectory("./content")
+	dir := filepath.Join("./content", sectionDirectory(parts[0]))
+	for _, category := range parts[1 : len(parts)-1] {
+		dir = filepath.Join(dir, "_"+strings.TrimPrefix(category, "_"))
+	}
+	createDirectory(dir)
+
+	newFile := filepath.Join(dir, slug+".md")
+	if _, err := os.Stat(newFile); err == nil {
+		log.Fatal("FATAL: ", newFile, " already exists")
+	}
+
+	archetype := Archetype{
+		Title:   displayName(slug),
+		Slug:    slug,
+		Date:    time.Now().Format("2006-01-02"),
+		Section: pageSection(dir + "/").Crumb,
+	}
+	if len(parts) > 2 {
+		archetype.Category = strings.TrimPrefix(parts[len(parts)-2], "_")
+	}
+
+	tmpl, err := template.New("archetype").Funcs(archetypeFuncs).Parse(archetypeTemplate(archetype.Section))
+	if err != nil {
+		log.Fatal("FATAL: ", err, " Could not parse archetype for ", archetype.Section, " in newContent(string)")
+	}
+
+	var processed bytes.Buffer
+	if err := tmpl.Execute(&processed, archetype); err != nil {
+		log.Fatal("FATAL: ", err, " Could not execute archetype for ", archetype.Section, " in newContent(string)")
+	}
+
+	if err := ioutil.WriteFile(newFile, processed.Bytes(), 0644); err != nil {
+		log.Fatal("FATAL: ", err, " Could not create ", newFile, " in newContent(string)")
+	}
+	log.Println("Created", newFile)
+}
+
+// Find the N_section directory for a section name, if there isn't one it's created after the last section
+func sectionDirectory(section string) string {
+	var sectionRe = regexp.MustCompile(`^(\d{1,5})_(.+)$`)
+	lastIndex := 0
+
+	entries, err := os.ReadDir("./content")
+	if err != nil {
+		log.Fatal("FATAL: ", err, " Could not read the content directory in sectionDirectory(string)")
+	}
+
+	for _, entry := range entries {
+		sectionMatches := sectionRe.FindStringSubmatch(entry.Name())
+		if !entry.IsDir() || sectionMatches == nil {
+			continue
+		}
+		if entry.Name() == section || sectionMatches[2] == section {
+			return entry.Name()
+		}
+		if index, _ := strconv.Atoi(sectionMatches[1]); index > lastIndex {
+			lastIndex = index
+		}
+	}
+
+	if sectionRe.MatchString(section) {
+		return section
+	}
+	return strconv.Itoa(lastIndex+1) + "_" + section
+}
+
+// The archetype for a section: archetypes/<section>.md, then archetypes/default.md, then the built in one
+func archetypeTemplate(section string) string {
+	for _, name := range []string{section + ".md", "default.md"} {
+		if content, err := ioutil.ReadFile(archetypeDirectory + name); err == nil {
+			return string(content)
+		}
+	}
+	return defaultArchetype
+}
+
+// Write the starter content and theme into siteDirectory, existing files are never overwritten
+func initSite(siteDirectory string) {
+	if _, err := os.Stat(filepath.Join(siteDirectory, configFile)); err == nil {
+		log.Fatal("FATAL: ", filepath.Join(siteDirectory, configFile), " already exists, not initializing over an existing site")
+	}
+
+	err := fs.WalkDir(starterFiles, "starter", func(currentFile string, info fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		outPath := filepath.Join(siteDirectory, strings.TrimPrefix(currentFile, "starter"))
+
+		if info.IsDir() {
+			createDirectory(outPath)
+			return nil
+		}
+		if _, err := os.Stat(outPath); err == nil {
+			log.Println("Skipping", outPath, "it already exists")
+			return nil
+		}
+
+		content, err := starterFiles.ReadFile(currentFile)
+		if err != nil {
+			return err
+		}
+		log.Println("Creating", outPath)
+		return ioutil.WriteFile(outPath, content, 0644)
+	})
+	if err != nil {
+		log.Fatal("FATAL: ", err, " Could not write starter files in initSite(string)")
+	}
+
+	log.Println("Site created, run gopubsite in", siteDirectory, "to build it into ./out")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRunCommand(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"no arguments", nil, false},
+		{"unknown command", []string{"serve"}, false},
+	}
+
+	for _, test := range tests {
+		if got := runCommand(test.args); got != test.want {
+			t.Errorf("%s: runCommand(%q) = %v, want %v", test.name, test.args, got, test.want)
+		}
+	}
+	if !strings.Contains(logged.String(), "WARNING: unknown command `serve`") {
+		t.Errorf("runCommand didn't warn about the unknown command, logged %q", logged.String())
+	}
+}
+
+func TestNewContent(t *testing.T) {
+	savedMeta := siteMeta
+	workingDirectory, _ := os.Getwd()
+	defer func() {
+		siteMeta = savedMeta
+		os.Chdir(workingDirectory)
+	}()
+	siteMeta = Config{}
+
+	// No ./content yet, newContent has to create it
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	newContent(`notes/say-"hi"\there`)
+
+	content, err := os.ReadFile(filepath.Join("content", "1_notes", `say-"hi"\there.md`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frontMatter struct {
+		Title string `yaml:"title"`
+	}
+	parts := strings.SplitN(string(content), "---", 3)
+	if len(parts) != 3 {
+		t.Fatalf("newContent wrote %q, want front matter between ---", content)
+	}
+	if err := yaml.Unmarshal([]byte(parts[1]), &frontMatter); err != nil {
+		t.Fatalf("newContent wrote front matter that isn't valid YAML: %v\n%s", err, content)
+	}
+	if want := `Say "Hi"\There`; frontMatter.Title != want {
+		t.Errorf("newContent title = %q, want %q", frontMatter.Title, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Data files for templates can go in either directory, when both have the same file the second one wins
+var dataDirectories = []string{"./content/.config/data", "./content/_data"}
+
+// Read every YAML, JSON, TOML and CSV file in the data directories into a nested map, directories and file
+// names (without the extension) become keys so data/talks/2022.yaml is {{index .Data "talks" "2022"}}
+func loadData() map[string]interface{} {
+	data := map[string]interface{}{}
+
+	for _, dataDirectory := range dataDirectories {
+		if info, err := os.Stat(dataDirectory); err != nil || !info.IsDir() {
+			continue
+		}
+		log.Println("Loading data files from", dataDirectory)
+
+		filepath.WalkDir(dataDirectory, func(currentFile string, info os.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				log.Print("ERROR: ", walkErr, " reading data directory ", dataDirectory)
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			value, ok := readDataFile(currentFile)
+			if !ok {
+				return nil
+			}
+
+			relFile, _ := filepath.Rel(dataDirectory, currentFile)
+			keys := strings.Split(filepath.ToSlash(strings.TrimSuffix(relFile, filepath.Ext(relFile))), "/")
+			parent := data
+			for _, key := range keys[:len(keys)-1] {
+				child, ok := parent[key].(map[string]interface{})
+				if !ok {
+					child = map[string]interface{}{}
+					parent[key] = child
+				}
+				parent = child
+			}
+			parent[keys[len(keys)-1]] = value
+			return nil
+		})
+	}
+
+	return data
+}
+
+// Decode a data file by its extension, CSV files become a list of rows keyed by the header row
+func readDataFile(dataFile string) (interface{}, bool) {
+	var value interface{}
+
+	content, err := os.ReadFile(dataFile)
+	if err != nil {
+		log.Print("ERROR: ", err, " Could not read data file ", dataFile)
+		return nil, false
+	}
+
+	switch strings.ToLower(filepath.Ext(dataFile)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &value)
+	case ".json":
+		err = json.Unmarshal(content, &value)
+	case ".toml":
+		err = toml.Unmarshal(content, &value)
+	case ".csv":
+		value, err = readCSV(content)
+	default:
+		log.Print("INFO: Skipping data file ", dataFile, ", expected .yaml, .yml, .json, .toml or .csv")
+		return nil, false
+	}
+
+	if err != nil {
+		log.Print("ERROR: ", err, " Could not decode data file ", dataFile)
+		return nil, false
+	}
+	return value, true
+}
+
+func readCSV(content []byte) ([]map[string]string, error) {
+	var rows []map[string]string
+
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil || len(records) == 0 {
+		return rows, err
+	}
+
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, header := range records[0] {
+			if i < len(record) {
+				row[header] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// The _data directory holds data files, not pages
+func isDataDirectory(currentFile string, info fs.DirEntry) bool {
+	return info.IsDir() && filepath.Clean(currentFile) == filepath.Join(sitePaths.Content, "_data")
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	newhtml "golang.org/x/net/html"
+)
+
+// Words per minute used for reading times
+const readingSpeed = 200
+
+// Asset paths (relative to assets/) mapped to the fingerprinted file they were written to
+var siteAssets = map[string]string{}
+
+// A group of pages from groupBy
+type PageGroup struct {
+	Key   string
+	Pages []Page
+}
+
+// Helper functions available in every template
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"dateFormat":  dateFormat,
+		"absURL":      absURL,
+		"relURL":      relURL,
+		"markdownify": markdownify,
+		"truncate":    truncate,
+		"summary":     summary,
+		"slugify":     slugify,
+		"where":       where,
+		"sortBy":      sortBy,
+		"groupBy":     groupBy,
+		"readingTime": readingTime,
+		"asset":       asset,
+		"i18n":        translate,
+	}
+}
+
+// Format a front matter date with a Go layout, e.g. {{dateFormat "January 2, 2006" .CurrentPage.Date}}
+// Month and day names are in the page's language, dates that can't be read are returned as they are
+func dateFormat(layout string, date string) string {
+	parsed := parseDate(date)
+	if parsed.IsZero() {
+		return date
+	}
+	return localDate(parsed, layout)
+}
+
+// A path made absolute with BaseURL, e.g. {{absURL "media/photo.jpg"}} is https://example.com/media/photo.jpg
+// Media and assets are shared by every language so this is always the site root, not the language's base path
+func absURL(path string) template.URL {
+	if isAbsolute(path) {
+		return template.URL(path)
+	}
+	return template.URL(strings.TrimSuffix(rootMeta.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/"))
+}
+
+// A path made relative to the site root using the path part of BaseURL, e.g. /blog/media/photo.jpg
+func relURL(path string) template.URL {
+	if isAbsolute(path) {
+		return template.URL(path)
+	}
+	root := ""
+	if base, err := url.Parse(rootMeta.BaseURL); err == nil {
+		root = strings.TrimSuffix(base.Path, "/")
+	}
+	return template.URL(root + "/" + strings.TrimPrefix(path, "/"))
+}
+
+func isAbsolute(path string) bool {
+	parsed, err := url.Parse(path)
+	return err == nil && parsed.IsAbs()
+}
+
+// Render markdown to HTML, a single paragraph isn't wrapped in <p> so it can be used inline
+func markdownify(text string) template.HTML {
+	var buf bytes.Buffer
+	if err := newMarkdown().Convert([]byte(text), &buf); err != nil {
+		log.Print("ERROR: ", err, " in markdownify(string)")
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	rendered := strings.TrimSpace(buf.String())
+	if strings.HasPrefix(rendered, "<p>") && strings.HasSuffix(rendered, "</p>") && strings.Count(rendered, "<p>") == 1 {
+		rendered = strings.TrimSuffix(strings.TrimPrefix(rendered, "<p>"), "</p>")
+	}
+	return template.HTML(rendered)
+}
+
+// Cut text down to at most length characters at a word boundary and add an ellipsis if anything was cut
+func truncate(length int, text string) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	runes := []rune(text)[:length]
+	cut := string(runes)
+	if i := strings.LastIndexAny(cut, " \t\n"); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \t\n.,;:") + "…"
+}
+
+// The first words of some HTML as plain text, e.g. {{summary 40 .CurrentPage.Content}}
+func summary(words int, content template.HTML) string {
+	fields := strings.Fields(plainText(string(content)))
+	if len(fields) <= words {
+		return strings.Join(fields, " ")
+	}
+	return strings.Join(fields[:words], " ") + "…"
+}
+
+// Minutes it takes to read some HTML, never less than 1
+func readingTime(content template.HTML) int {
+	return readingMinutes(wordCount(string(content)))
+}
+
+// Tags that start a new block of text, plainText puts a space between their text and the text around them
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true, "details": true,
+	"div": true, "dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "summary": true, "table": true, "td": true, "th": true,
+	"tr": true, "ul": true,
+}
+
+// The text of some HTML with the tags removed, the "#" links headinganchors adds to headings are left out
+// Inline tags like <code> and <em> are joined to the text around them, blocks are separated by a space
+func plainText(htmlString string) string {
+	var text strings.Builder
+	tokenizer := newhtml.NewTokenizer(strings.NewReader(htmlString))
+	inAnchor := false
+
+	for {
+		tt := tokenizer.Next()
+		if tt == newhtml.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				log.Print("ERROR: tokenizer: ", tokenizer.Err(), " in plainText")
+			}
+			break
+		}
+		if tt == newhtml.StartTagToken || tt == newhtml.EndTagToken || tt == newhtml.SelfClosingTagToken {
+			name, hasAttr := tokenizer.TagName()
+			if string(name) == "a" {
+				inAnchor = tt == newhtml.StartTagToken && hasAttr && isHeadingAnchor(tokenizer)
+			} else if blockElements[string(name)] {
+				text.WriteString(" ")
+			}
+		}
+		if tt == newhtml.TextToken && !inAnchor {
+			text.Write(tokenizer.Text())
+		}
+	}
+
+	return text.String()
+}
+
+// A Page field by name, for where, sortBy and groupBy
+func pageField(currentPage Page, field string) reflect.Value {
+	value := reflect.ValueOf(currentPage).FieldByName(field)
+	if !value.IsValid() {
+		log.Print("ERROR: Page has no field `", field, "`")
+	}
+	return value
+}
+
+func fieldString(value reflect.Value) string {
+	if !value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// Pages where a field equals a value, e.g. {{where .Site.Pages "Section" "Homelab Notes"}}
+func where(pages []Page, field string, value interface{}) []Page {
+	var matches []Page
+	for _, currentPage := range pages {
+		if fieldString(pageField(currentPage, field)) == fmt.Sprint(value) {
+			matches = append(matches, currentPage)
+		}
+	}
+	return matches
+}
+
+// A sorted copy of pages by a field, "asc" or "desc", e.g. {{sortBy .Site.Pages "Date" "desc"}}
+func sortBy(pages []Page, field string, order string) []Page {
+	sorted := append([]Page(nil), pages...)
+
+	less := func(i, j int) bool {
+		a, b := pageField(sorted[i], field), pageField(sorted[j], field)
+		if !a.IsValid() || !b.IsValid() {
+			return false
+		}
+		switch {
+		case field == "Date":
+			return parseDate(a.String()).Before(parseDate(b.String()))
+		case a.CanInt():
+			return a.Int() < b.Int()
+		case a.CanFloat():
+			return a.Float() < b.Float()
+		default:
+			return strings.ToLower(fieldString(a)) < strings.ToLower(fieldString(b))
+		}
+	}
+
+	if order == "desc" {
+		sort.SliceStable(sorted, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(sorted, less)
+	}
+	return sorted
+}
+
+// Pages grouped by a field in the order each value is first seen, e.g. {{range groupBy .Site.Pages "Category"}}
+func groupBy(pages []Page, field string) []PageGroup {
+	var groups []PageGroup
+	positions := map[string]int{}
+
+	for _, currentPage := range pages {
+		key := fieldString(pageField(currentPage, field))
+		if _, ok := positions[key]; !ok {
+			positions[key] = len(groups)
+			groups = append(groups, PageGroup{Key: key})
+		}
+		groups[positions[key]].Pages = append(groups[positions[key]].Pages, currentPage)
+	}
+	return groups
+}
+
+// The URL of a theme asset, fingerprinted when `fingerprint` is set, e.g. {{asset "css/style.css"}}
+func asset(path string) template.URL {
+	path = strings.TrimPrefix(path, "/")
+	if fingerprinted, ok := siteAssets[path]; ok {
+		path = fingerprinted
+	}
+	return absURL("assets/" + path)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{"(like <code>1_notes</code>)", "(like 1_notes)"},
+		{"<p><em>un</em>believable</p>", "unbelievable"},
+		{"<p>one</p><p>two</p>", "one two"},
+		{"<ul><li>one</li><li>two</li></ul>", "one two"},
+		{"one<br>two<br/>three", "one two three"},
+		{"<h2>Title</h2>text", "Title text"},
+		{"<p>a &amp; b</p>", "a & b"},
+		{"<h2 id=\"title\">Title <a class=\"anchor\" href=\"#title\">#</a></h2><p>Text</p>", "Title Text"},
+		{"<p>see <a href=\"/notes\">the notes</a>.</p>", "see the notes."},
+	}
+
+	for _, test := range tests {
+		if got := strings.Join(strings.Fields(plainText(test.html)), " "); got != test.want {
+			t.Errorf("plainText(%q) = %q, want %q", test.html, got, test.want)
+		}
+	}
+}
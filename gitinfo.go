@@ -0,0 +1,147 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The last commit that changed a content file
+type GitInfo struct {
+	Hash        string
+	ShortHash   string
+	AuthorName  string
+	AuthorEmail string
+	Date        string // RFC3339
+	Subject     string
+}
+
+// Last commits keyed by the file's path from the root of the repository, only filled in with `gitinfo: true`
+var siteGitInfo = map[string]GitInfo{}
+
+var gitRoot string
+var gitBranch string
+var gitRepositoryUrl string
+
+// git@github.com:user/repo.git or https://github.com/user/repo.git
+var githubRemoteRe = regexp.MustCompile(`github\.com[:/]([^/]+/[^/]+?)(?:\.git)?/?$`)
+
+// Read the last commit for every file in the content directory with one `git log`
+func loadGitInfo() {
+	if !siteMeta.GitInfo {
+		return
+	}
+
+	root, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		log.Print("ERROR: ", err, " gitinfo is set but ", sitePaths.Content, " isn't in a git repository, leaving out git metadata")
+		return
+	}
+	gitRoot = root
+
+	// A shallow clone (the default for actions/checkout) only has the latest commit, every file would look
+	// like it was last changed in it
+	if shallow, err := git("rev-parse", "--is-shallow-repository"); err == nil && shallow == "true" {
+		log.Print("WARNING: ", gitRoot, " is a shallow clone so the last commit of each file isn't known, leaving out git dates and commits (check out the full history, e.g. fetch-depth: 0)")
+	} else {
+		readGitHistory()
+	}
+
+	if branch, err := git("rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		gitBranch = branch
+	} else {
+		gitBranch = "main"
+	}
+	gitRepositoryUrl = githubRepository()
+}
+
+// Fill siteGitInfo from one `git log` of the whole history
+func readGitHistory() {
+	history, err := git("-c", "core.quotePath=false", "log", "--no-merges", "--name-only", "--format=%x1e%H%x1f%h%x1f%an%x1f%ae%x1f%aI%x1f%s", "--", ".")
+	if err != nil {
+		log.Print("ERROR: ", err, " Could not read the git history in readGitHistory()")
+		return
+	}
+
+	// Commits are newest first so the first one seen for a file is its last change
+	for _, record := range strings.Split(history, "\x1e")[1:] {
+		lines := strings.Split(record, "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+		info := GitInfo{Hash: fields[0], ShortHash: fields[1], AuthorName: fields[2], AuthorEmail: fields[3], Date: fields[4], Subject: fields[5]}
+
+		for _, file := range lines[1:] {
+			if _, ok := siteGitInfo[file]; file != "" && !ok {
+				siteGitInfo[file] = info
+			}
+		}
+	}
+}
+
+// Run git in the content directory and return what it printed without the trailing newline
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", sitePaths.Content}, args...)...).Output()
+	return strings.TrimRight(string(out), "\n"), err
+}
+
+// The GitHub repository for edit and history links: `github` in config.yaml when it points at a repository
+// (https://github.com/user/site), otherwise the origin remote when it's on GitHub
+func githubRepository() string {
+	if github, err := url.Parse(siteMeta.Github); err == nil && github.Host == "github.com" && strings.Count(strings.Trim(github.Path, "/"), "/") == 1 {
+		return "https://github.com/" + strings.TrimSuffix(strings.Trim(github.Path, "/"), ".git")
+	}
+	if remote, err := git("remote", "get-url", "origin"); err == nil {
+		if remoteMatches := githubRemoteRe.FindStringSubmatch(remote); remoteMatches != nil {
+			return "https://github.com/" + remoteMatches[1]
+		}
+	}
+	return ""
+}
+
+// A content file's path from the root of the repository, "" if it isn't in it
+func gitPath(workingFile string) string {
+	if gitRoot == "" {
+		return ""
+	}
+	if realFile, err := filepath.EvalSymlinks(workingFile); err == nil {
+		workingFile = realFile
+	}
+	rel, err := filepath.Rel(gitRoot, workingFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// The last commit for a page and the date it last changed, which includes changes to the files it includes
+func pageGitInfo(workingFile string, includes []string) (*GitInfo, string) {
+	info, ok := siteGitInfo[gitPath(workingFile)]
+	if !ok {
+		return nil, ""
+	}
+
+	lastMod := info.Date
+	for _, include := range includes {
+		if includeInfo, ok := siteGitInfo[gitPath(filepath.Join(sitePaths.Content, include))]; ok && parseDate(includeInfo.Date).After(parseDate(lastMod)) {
+			lastMod = includeInfo.Date
+		}
+	}
+	return &info, lastMod
+}
+
+// "Edit this page" and "view history" links on GitHub, empty when the repository isn't known
+func gitLinks(workingFile string) (template.URL, template.URL) {
+	filePath := gitPath(workingFile)
+	if gitRepositoryUrl == "" || filePath == "" {
+		return "", ""
+	}
+	escaped := (&url.URL{Path: filePath}).EscapedPath()
+	return template.URL(gitRepositoryUrl + "/edit/" + gitBranch + "/" + escaped),
+		template.URL(gitRepositoryUrl + "/commits/" + gitBranch + "/" + escaped)
+}
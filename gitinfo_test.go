@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPageGitInfo(t *testing.T) {
+	savedPaths, savedRoot, savedInfo := sitePaths, gitRoot, siteGitInfo
+	defer func() { sitePaths, gitRoot, siteGitInfo = savedPaths, savedRoot, savedInfo }()
+
+	gitRoot = t.TempDir()
+	sitePaths.Content = filepath.Join(gitRoot, "content")
+	page := GitInfo{ShortHash: "aaa", Date: "2024-02-01T10:00:00Z"}
+	siteGitInfo = map[string]GitInfo{
+		"content/1_notes/page.md":   page,
+		"content/_includes/new.md":  {ShortHash: "bbb", Date: "2024-03-01T10:00:00Z"},
+		"content/_includes/old.md":  {ShortHash: "ccc", Date: "2024-01-01T10:00:00Z"},
+		"content/1_notes/shared.md": {ShortHash: "ddd", Date: "2024-02-15T10:00:00Z"},
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		includes    []string
+		wantInfo    *GitInfo
+		wantLastMod string
+	}{
+		{"no includes", "1_notes/page.md", nil, &page, "2024-02-01T10:00:00Z"},
+		{"newer include", "1_notes/page.md", []string{"/_includes/old.md", "/_includes/new.md"}, &page, "2024-03-01T10:00:00Z"},
+		{"older include", "1_notes/page.md", []string{"/_includes/old.md"}, &page, "2024-02-01T10:00:00Z"},
+		{"newest of several", "1_notes/page.md", []string{"/1_notes/shared.md", "/_includes/old.md"}, &page, "2024-02-15T10:00:00Z"},
+		{"include not committed", "1_notes/page.md", []string{"/_includes/draft.md"}, &page, "2024-02-01T10:00:00Z"},
+		{"page not committed", "1_notes/draft.md", []string{"/_includes/new.md"}, nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, lastMod := pageGitInfo(filepath.Join(sitePaths.Content, test.file), test.includes)
+			if !reflect.DeepEqual(info, test.wantInfo) || lastMod != test.wantLastMod {
+				t.Errorf("pageGitInfo(%q, %q) = %v, %q, want %v, %q", test.file, test.includes, info, lastMod, test.wantInfo, test.wantLastMod)
+			}
+		})
+	}
+}
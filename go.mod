@@ -3,6 +3,7 @@ module github.com/queue_bit/gopubsite
 go 1.18
 
 require (
+	github.com/pelletier/go-toml/v2 v2.0.5
 	github.com/yuin/goldmark v1.5.2
 	github.com/yuin/goldmark-meta v1.1.0
 	golang.org/x/exp v0.0.0-20221002003631-540bb7301a08
@@ -26,7 +27,6 @@ require (
 	github.com/mattn/go-isatty v0.0.16 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
-	github.com/pelletier/go-toml/v2 v2.0.5 // indirect
 	github.com/ugorji/go/codec v1.2.7 // indirect
 	golang.org/x/crypto v0.0.0-20220926161630-eccd6366d1be // indirect
 	golang.org/x/sys v0.0.0-20220928140112-f11e5e49a4ec // indirect
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Translations in content/.config/i18n/<lang>.yaml replace the ones in the template's i18n/<lang>.yaml
+var i18nDirectory string = "./content/.config/i18n"
+
+// Translation bundles keyed by language code, each a map of message keys to strings (or lists for month and day names)
+var siteI18n = map[string]map[string]interface{}{}
+
+// Keys that have already been logged as missing so they're only logged once
+var missingI18n = map[string]bool{}
+
+// Read the bundle for every language from content/.config/i18n/ and each theme, the first one to have a key wins
+// English is always loaded as the last fallback
+func loadI18n() {
+	siteI18n = map[string]map[string]interface{}{}
+	missingI18n = map[string]bool{}
+
+	var sources []fs.FS
+	if info, err := os.Stat(i18nDirectory); err == nil && info.IsDir() {
+		sources = append(sources, os.DirFS(i18nDirectory))
+	}
+	for _, theme := range siteThemes {
+		if files, err := fs.Sub(theme.Files, "i18n"); err == nil {
+			sources = append(sources, files)
+		}
+	}
+
+	var codes []string
+	for _, lang := range siteLanguageList {
+		codes = append(codes, strings.ToLower(lang.Code), strings.ToLower(strings.SplitN(lang.Code, "-", 2)[0]))
+	}
+	for _, code := range append(codes, "en") {
+		if code == "" || siteI18n[code] != nil {
+			continue
+		}
+		bundle := map[string]interface{}{}
+		for _, files := range sources {
+			content, err := fs.ReadFile(files, code+".yaml")
+			if err != nil {
+				continue
+			}
+			messages := map[string]interface{}{}
+			if err := yaml.Unmarshal(content, &messages); err != nil {
+				log.Print("ERROR: ", err, " Could not read i18n/", code, ".yaml")
+				continue
+			}
+			for key, message := range messages {
+				if _, ok := bundle[key]; !ok {
+					bundle[key] = message
+				}
+			}
+		}
+		siteI18n[code] = bundle
+	}
+}
+
+// The bundles to look in for the language being built: its own, the base language (fr for fr-ca),
+// the default language, then English
+func i18nLanguages() []string {
+	var codes []string
+	seen := map[string]bool{}
+
+	candidates := []string{siteLanguage.Code, strings.SplitN(siteLanguage.Code, "-", 2)[0]}
+	if len(siteLanguageList) > 0 {
+		candidates = append(candidates, siteLanguageList[0].Code, strings.SplitN(siteLanguageList[0].Code, "-", 2)[0])
+	}
+	for _, code := range append(candidates, "en") {
+		code = strings.ToLower(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+// Find a message in the current language, e.g. {{i18n "related"}} or {{i18n "minread" 5}}
+// A message can use the value passed with it as {{.}}, a key that isn't in any bundle is returned as it is
+func translate(key string, data ...interface{}) string {
+	for _, code := range i18nLanguages() {
+		message, ok := siteI18n[code][key].(string)
+		if !ok {
+			continue
+		}
+		if !strings.Contains(message, "{{") {
+			return message
+		}
+
+		var value interface{}
+		if len(data) > 0 {
+			value = data[0]
+		}
+		var translated strings.Builder
+		parsed, err := template.New(key).Parse(message)
+		if err == nil {
+			err = parsed.Execute(&translated, value)
+		}
+		if err != nil {
+			log.Print("ERROR: ", err, " in the ", code, " translation of ", key)
+			return message
+		}
+		return translated.String()
+	}
+
+	if !missingI18n[key] {
+		missingI18n[key] = true
+		log.Print("ERROR: no translation for ", key, " in i18n/", siteLanguage.Code, ".yaml")
+	}
+	return key
+}
+
+// The name at index in a list from the current language's bundle (months, monthsshort, days, daysshort)
+func translateName(key string, index int, fallback string) string {
+	for _, code := range i18nLanguages() {
+		if names, ok := siteI18n[code][key].([]interface{}); ok && index < len(names) {
+			if name, ok := names[index].(string); ok {
+				return name
+			}
+		}
+	}
+	return fallback
+}
+
+// Format a date with a Go layout using the current language's month and day names
+// The names are swapped for placeholders Format leaves alone, then for the translations afterwards
+func localDate(date time.Time, layout string) string {
+	names := []struct {
+		token       string
+		placeholder string
+		name        string
+	}{
+		{"January", "\x00", translateName("months", int(date.Month())-1, date.Month().String())},
+		{"Jan", "\x01", translateName("monthsshort", int(date.Month())-1, date.Month().String()[:3])},
+		{"Monday", "\x02", translateName("days", int(date.Weekday()), date.Weekday().String())},
+		{"Mon", "\x03", translateName("daysshort", int(date.Weekday()), date.Weekday().String()[:3])},
+	}
+
+	for _, name := range names {
+		layout = strings.ReplaceAll(layout, name.token, name.placeholder)
+	}
+	formatted := date.Format(layout)
+	for _, name := range names {
+		formatted = strings.ReplaceAll(formatted, name.placeholder, name.name)
+	}
+
+	return formatted
+}
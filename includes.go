@@ -0,0 +1,144 @@
+package main
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// The files an include is nested in (the page first) and every file included into the page so far
+type includeStack struct {
+	files    []string
+	included []string
+}
+
+// A markdown heading line, e.g. "## Install steps" or "## Install steps {#install}"
+var headingLineRe = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?(?:[ \t]*\{[^}]*#([^}\s]+)[^}]*\})?[ \t]*$`)
+
+// The markdown of another content file or one part of it, e.g. {{< include "setup.md" >}} or {{< include "setup.md#install" >}}
+// Paths are relative to the including file, or to the content directory when they start with "/", and can't
+// lead outside the content directory
+func includeFile(shortcode Shortcode, includes *includeStack) string {
+	includePath := shortcode.Get("file")
+	if includePath == "" {
+		includePath = shortcode.Get(0)
+	}
+	fragment := shortcode.Get("section")
+	if i := strings.Index(includePath, "#"); i >= 0 {
+		includePath, fragment = includePath[:i], includePath[i+1:]
+	}
+	if includePath == "" {
+		log.Print("ERROR: include without a file in ", shortcode.File)
+		return ""
+	}
+
+	includedFile := filepath.Join(filepath.Dir(shortcode.File), includePath)
+	if strings.HasPrefix(includePath, "/") {
+		includedFile = filepath.Join(sitePaths.Content, includePath)
+	}
+	if rel, err := filepath.Rel(sitePaths.Content, includedFile); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		log.Print("ERROR: ", includePath, " is outside the content directory, skipping the include in ", shortcode.File)
+		return ""
+	}
+
+	if slices.Contains(includes.files, includedFile) {
+		var loop []string
+		for _, file := range append(includes.files, includedFile) {
+			loop = append(loop, contentPath(file))
+		}
+		log.Print("ERROR: include loop ", strings.Join(loop, " -> "), ", skipping the include in ", shortcode.File)
+		return ""
+	}
+
+	content, err := ioutil.ReadFile(includedFile)
+	if err != nil {
+		log.Print("ERROR: ", err, " Could not include ", includePath, " in ", shortcode.File)
+		return ""
+	}
+	if !slices.Contains(includes.included, contentPath(includedFile)) {
+		includes.included = append(includes.included, contentPath(includedFile))
+	}
+
+	markdown := stripFrontMatter(string(content))
+	if fragment != "" {
+		var found bool
+		if markdown, found = markdownFragment(markdown, fragment); !found {
+			log.Print("ERROR: no heading `", fragment, "` in ", includePath, " included in ", shortcode.File)
+			return ""
+		}
+	}
+
+	includes.files = append(includes.files, includedFile)
+	defer func() { includes.files = includes.files[:len(includes.files)-1] }()
+
+	return expandShortcodes(markdown, includedFile, includes)
+}
+
+// A file's path from the content directory, e.g. /1_homelab-notes/_projects/setup.md
+func contentPath(file string) string {
+	if rel, err := filepath.Rel(sitePaths.Content, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return "/" + filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// Markdown without its --- front matter ---
+func stripFrontMatter(markdown string) string {
+	if !strings.HasPrefix(markdown, "---\n") && !strings.HasPrefix(markdown, "---\r\n") {
+		return markdown
+	}
+	if end := strings.Index(markdown[3:], "\n---"); end >= 0 {
+		rest := markdown[3+end+4:]
+		return strings.TrimPrefix(strings.TrimPrefix(rest, "\r"), "\n")
+	}
+	return markdown
+}
+
+// A heading and everything under it up to the next heading at the same level or higher, the heading can be
+// given by its text, its id or its {#custom-id} (e.g. "Install steps" or "install-steps")
+// Headings inside fenced code blocks are ignored
+func markdownFragment(markdown string, heading string) (string, bool) {
+	lines := strings.SplitAfter(markdown, "\n")
+	start, level := -1, 0
+	fence := ""
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		headingMatches := headingLineRe.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if headingMatches == nil {
+			continue
+		}
+		if start >= 0 && len(headingMatches[1]) <= level {
+			return strings.Join(lines[start:i], ""), true
+		}
+		if start < 0 && (headingMatches[3] == heading || slugify(headingMatches[2]) == slugify(heading)) {
+			start, level = i, len(headingMatches[1])
+		}
+	}
+
+	if start < 0 {
+		return "", false
+	}
+	return strings.Join(lines[start:], ""), true
+}
+
+// content/_includes holds files that are only included into other pages, they aren't pages themselves
+func isIncludesDirectory(currentFile string, info fs.DirEntry) bool {
+	return info.IsDir() && filepath.Clean(currentFile) == filepath.Join(sitePaths.Content, "_includes")
+}
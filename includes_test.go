@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStripFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"front matter", "---\ntitle: Setup\n---\n# Setup\n", "# Setup\n"},
+		{"windows line endings", "---\r\ntitle: Setup\r\n---\r\n# Setup\r\n", "# Setup\r\n"},
+		{"empty front matter", "---\n---\nbody", "body"},
+		{"no front matter", "# Setup\n\n---\n\nmore", "# Setup\n\n---\n\nmore"},
+		{"unclosed front matter", "---\ntitle: Setup\n", "---\ntitle: Setup\n"},
+		{"empty", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := stripFrontMatter(test.markdown); got != test.want {
+				t.Errorf("stripFrontMatter(%q) = %q, want %q", test.markdown, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownFragment(t *testing.T) {
+	markdown := "# Setup\n" +
+		"intro\n" +
+		"## Install steps\n" +
+		"install\n" +
+		"### Linux\n" +
+		"linux\n" +
+		"## Configure {#config}\n" +
+		"```sh\n" +
+		"# not a heading\n" +
+		"```\n" +
+		"configure\n" +
+		"~~~\n" +
+		"## Also not a heading\n" +
+		"~~~\n" +
+		"# Next\n" +
+		"next\n"
+
+	tests := []struct {
+		name      string
+		heading   string
+		want      string
+		wantFound bool
+	}{
+		{"by text", "Install steps", "## Install steps\ninstall\n### Linux\nlinux\n", true},
+		{"by id", "install-steps", "## Install steps\ninstall\n### Linux\nlinux\n", true},
+		{"by custom id", "config", "## Configure {#config}\n```sh\n# not a heading\n```\nconfigure\n~~~\n## Also not a heading\n~~~\n", true},
+		{"deepest level", "Linux", "### Linux\nlinux\n", true},
+		{"up to a higher level", "Setup", "# Setup\nintro\n## Install steps\ninstall\n### Linux\nlinux\n## Configure {#config}\n```sh\n# not a heading\n```\nconfigure\n~~~\n## Also not a heading\n~~~\n", true},
+		{"to the end", "Next", "# Next\nnext\n", true},
+		{"inside a code fence", "not a heading", "", false},
+		{"inside a tilde fence", "Also not a heading", "", false},
+		{"missing", "Uninstall", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := markdownFragment(markdown, test.heading)
+			if got != test.want || found != test.wantFound {
+				t.Errorf("markdownFragment(%q) = %q, %v, want %q, %v", test.heading, got, found, test.want, test.wantFound)
+			}
+		})
+	}
+}
+
+func TestIncludeFile(t *testing.T) {
+	savedPaths := sitePaths
+	defer func() { sitePaths = savedPaths }()
+
+	root := t.TempDir()
+	sitePaths.Content = filepath.Join(root, "content")
+	files := map[string]string{
+		"content/1_notes/page.md":        "",
+		"content/1_notes/shared.md":      "---\ntitle: Shared\n---\nshared steps\n",
+		"content/_includes/setup.md":     "# Setup\nsetup steps\n## Install\ninstall steps\n",
+		"content/_includes/self.md":      "{{< include \"self.md\" >}}",
+		"outside.md":                     "outside the content directory\n",
+		"content-other/sneaky.md":        "next to the content directory\n",
+		"content/1_notes/_drafts/old.md": "an old draft\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		include      string
+		want         string
+		wantIncluded []string
+	}{
+		{"relative", "shared.md", "shared steps\n", []string{"/1_notes/shared.md"}},
+		{"sub directory", "_drafts/old.md", "an old draft\n", []string{"/1_notes/_drafts/old.md"}},
+		{"from the content directory", "/_includes/setup.md", "# Setup\nsetup steps\n## Install\ninstall steps\n", []string{"/_includes/setup.md"}},
+		{"fragment", "/_includes/setup.md#install", "## Install\ninstall steps\n", []string{"/_includes/setup.md"}},
+		{"up and back into content", "../_includes/setup.md#install", "## Install\ninstall steps\n", []string{"/_includes/setup.md"}},
+		{"loop", "/_includes/self.md", "", []string{"/_includes/self.md"}},
+		{"missing fragment", "shared.md#nope", "", []string{"/1_notes/shared.md"}},
+		{"missing file", "nope.md", "", nil},
+		{"outside the content directory", "../../outside.md", "", nil},
+		{"outside from the content directory", "/../outside.md", "", nil},
+		{"directory starting with content", "../../content-other/sneaky.md", "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := filepath.Join(sitePaths.Content, "1_notes/page.md")
+			includes := &includeStack{files: []string{page}}
+			shortcode := Shortcode{Name: "include", Args: []string{test.include}, File: page}
+
+			got := includeFile(shortcode, includes)
+			if got != test.want || !reflect.DeepEqual(includes.included, test.wantIncluded) {
+				t.Errorf("includeFile(%q) = %q, %q, want %q, %q", test.include, got, includes.included, test.want, test.wantIncluded)
+			}
+		})
+	}
+}
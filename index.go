@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Front matter and body from an optional _index.md placed in a section or category directory
+type IndexMeta struct {
+	Title       string
+	Description string
+	Icon        string
+	Intro       template.HTML
+	Content     template.HTML
+	Weight      int
+	Sort        string
+	TocEntries  []TocEntry
+}
+
+// A section or category from the `sections` block in config.yaml, for directories without an _index.md
+type SectionConfig struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Icon        string `yaml:"icon"`
+}
+
+// Index metadata keyed by "section" or "section/category"
+var siteIndexes = map[string]IndexMeta{}
+
+// Read the front matter and body of a section or category _index.md
+func parseIndex(workingFile string) IndexMeta {
+	content, err := ioutil.ReadFile(workingFile)
+	if err != nil {
+		log.Fatal("FATAL: ", err, " workingFile: ", workingFile, " in parseIndex(string)->ReadFile")
+	}
+
+	rendered, frontMatter, tocEntries := renderMarkdown(content)
+	if !tocEnabled(frontMatter) {
+		tocEntries = nil
+	}
+
+	return IndexMeta{
+		Title:       metaString(frontMatter, "title"),
+		Description: metaString(frontMatter, "description"),
+		Icon:        metaString(frontMatter, "icon"),
+		Intro:       template.HTML(metaString(frontMatter, "intro")),
+		Content:     template.HTML(rendered),
+		Weight:      metaInt(frontMatter, "weight"),
+		Sort:        metaString(frontMatter, "sort"),
+		TocEntries:  tocEntries,
+	}
+}
+
+// Read every _index.md for the language being built, _index.fr.md and so on for other languages
+// A language without its own _index file still gets the default language's weight and sort
+func loadIndexes() {
+	siteIndexes = map[string]IndexMeta{}
+	fallbacks := map[string]IndexMeta{}
+
+	filepath.WalkDir(sitePaths.Content, func(currentFile string, info fs.DirEntry, err error) error {
+		if err != nil {
+			log.Fatalf("FATAL ERROR: %s", err.Error())
+		}
+		if info.IsDir() && (info.Name() == ".config" || isDataDirectory(currentFile, info) || isIncludesDirectory(currentFile, info)) {
+			return filepath.SkipDir
+		}
+
+		lang, name := fileLanguage(info.Name())
+		if info.IsDir() || name != "_index.md" {
+			return nil
+		}
+
+		key := indexKey(strings.TrimPrefix(currentFile, sitePaths.Content))
+		if lang == siteLanguage.Code {
+			siteIndexes[key] = parseIndex(currentFile)
+		} else if lang == siteLanguageList[0].Code {
+			fallbacks[key] = parseIndex(currentFile)
+		}
+		return nil
+	})
+
+	for key, fallback := range fallbacks {
+		index, ok := siteIndexes[key]
+		if !ok {
+			index = IndexMeta{}
+		}
+		if index.Weight == 0 {
+			index.Weight = fallback.Weight
+		}
+		if index.Sort == "" {
+			index.Sort = fallback.Sort
+		}
+		siteIndexes[key] = index
+	}
+
+	for key, sectionConfig := range siteMeta.Sections {
+		key = strings.Trim(key, "/")
+		index := siteIndexes[key]
+		if index.Title == "" {
+			index.Title = sectionConfig.Title
+		}
+		if index.Description == "" {
+			index.Description = sectionConfig.Description
+		}
+		if index.Icon == "" {
+			index.Icon = sectionConfig.Icon
+		}
+		siteIndexes[key] = index
+	}
+}
+
+// The key an _index.md (or anything else in the same directory) is stored under, e.g. "section/category/subcategory"
+func indexKey(relFile string) string {
+	return strings.Join(pageDirs(relFile), "/")
+}
+
+// Return a front matter value as a string, or "" if it isn't set
+func metaString(frontMatter map[string]interface{}, key string) string {
+	if frontMatter[key] == nil {
+		return ""
+	}
+	if v, ok := frontMatter[key].(string); ok {
+		return v
+	}
+	return fmt.Sprintf("%v", frontMatter[key])
+}
+
+// Return a front matter value as an int, or 0 if it isn't set or isn't a number
+func metaInt(frontMatter map[string]interface{}, key string) int {
+	switch v := frontMatter[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		i, _ := strconv.Atoi(strings.TrimSpace(v))
+		return i
+	}
+	return 0
+}
+
+// Dates in front matter are free-form strings, try the common layouts
+func parseDate(date string) time.Time {
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04:05 -0700 MST", "2006-01-02", "January 2, 2006", "Jan 2, 2006"}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(date)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// The sort order for a section or category: its own _index.md, then the closest parent _index.md that sets one,
+// then config.yaml, then weight
+func sortOrder(key string) string {
+	for key != "" {
+		if order := siteIndexes[key].Sort; order != "" {
+			return order
+		}
+		if i := strings.LastIndex(key, "/"); i >= 0 {
+			key = key[:i]
+		} else {
+			key = ""
+		}
+	}
+	if siteMeta.Sort != "" {
+		return siteMeta.Sort
+	}
+	return "weight"
+}
+
+// Sort pages in place by "weight" (lowest first), "date" (newest first) or "title", ties keep their existing order
+func sortPages(pages []Page, order string) {
+	switch order {
+	case "date":
+		sort.SliceStable(pages, func(i, j int) bool {
+			return parseDate(pages[i].Date).After(parseDate(pages[j].Date))
+		})
+	case "title":
+		sort.SliceStable(pages, func(i, j int) bool {
+			return strings.ToLower(pages[i].Title) < strings.ToLower(pages[j].Title)
+		})
+	case "weight":
+		sort.SliceStable(pages, func(i, j int) bool {
+			return pages[i].Weight < pages[j].Weight
+		})
+	default:
+		log.Print("ERROR: unknown sort order `", order, "`, expected weight, date or title")
+	}
+}
+
+// Sort categories in place by their _index.md weight, or by title if their parent sorts by title
+func sortNodes(nodes []*Node, order string) {
+	if order == "title" {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return strings.ToLower(nodeTitle(nodes[i])) < strings.ToLower(nodeTitle(nodes[j]))
+		})
+	} else {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return siteIndexes[nodes[i].Key].Weight < siteIndexes[nodes[j].Key].Weight
+		})
+	}
+}
+
+// Sections are ordered by their numeric prefix unless their _index.md sets a weight
+func sortSections(sections []Section) {
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sectionWeight(sections[i]) < sectionWeight(sections[j])
+	})
+}
+
+func sectionWeight(section Section) int {
+	if weight := siteIndexes[section.Crumb].Weight; weight != 0 {
+		return weight
+	}
+	return section.Index
+}
+
+// The _index.md (or config.yaml `sections`) title for a section or category if it has one, otherwise the fallback
+func indexTitle(key string, fallback string) string {
+	if title := siteIndexes[key].Title; title != "" {
+		return title
+	}
+	return fallback
+}
+
+// A title for a section or category directory name, dashes and underscores become spaces and each word is
+// capitalized, except words listed in `acronyms` (aws becomes AWS) and words that already have capitals in them
+func displayName(slug string) string {
+	acronyms := map[string]string{}
+	for _, acronym := range siteMeta.Acronyms {
+		acronyms[strings.ToLower(acronym)] = acronym
+	}
+
+	words := strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' })
+	for i, word := range words {
+		if acronym, ok := acronyms[strings.ToLower(word)]; ok {
+			words[i] = acronym
+		} else if strings.ToLower(word) == word {
+			words[i] = titleCase(word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// The _index.md (or config.yaml `sections`) description for a section or category if it has one, otherwise the fallback
+func indexDescription(key string, fallback string) string {
+	if description := siteIndexes[key].Description; description != "" {
+		return description
+	}
+	return fallback
+}
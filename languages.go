@@ -0,0 +1,182 @@
+package main
+
+import (
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// A language from the `languages` block in config.yaml
+type LanguageConfig struct {
+	Name     string `yaml:"name"`
+	Title    string `yaml:"title"`
+	BasePath string `yaml:"basepath"`
+	Weight   int    `yaml:"weight"`
+}
+
+// A language the site is built in, pages in it are written under BasePath
+type Language struct {
+	Code     string
+	Name     string
+	Title    string
+	BasePath string // "" for the default language, "/fr" and so on for the others
+	Url      template.URL
+	Default  bool
+}
+
+// A language's pages and site data, built before any language is rendered so pages can link to their translations
+type languageBuild struct {
+	Language  Language
+	Pages     []Page
+	Site      Site
+	PageLinks map[string]PageLinks
+}
+
+// Another language's version of a page
+type Translation struct {
+	Lang         string
+	LanguageName string
+	Title        string
+	Url          template.URL
+}
+
+// The languages the site is built in, default first, and the one being built right now
+// Sites without a `languages` block have one language with no code
+var siteLanguageList []Language
+var siteLanguage Language
+
+// config.yaml and the out directory as they were before the language was applied
+var rootMeta Config
+var rootOutput string
+
+// Work out siteLanguageList from config.yaml, the default language is `defaultlanguage`, otherwise the one
+// without a basepath, otherwise the first by weight
+func loadLanguages() {
+	rootMeta = siteMeta
+	rootOutput = sitePaths.Output
+	siteLanguageList = nil
+
+	if len(siteMeta.Languages) == 0 {
+		siteLanguageList = []Language{{Code: siteMeta.DefaultLanguage, Title: siteMeta.Title, Url: template.URL(siteMeta.BaseURL + "/"), Default: true}}
+		siteLanguage = siteLanguageList[0]
+		return
+	}
+
+	var codes []string
+	for code := range siteMeta.Languages {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		a, b := siteMeta.Languages[codes[i]], siteMeta.Languages[codes[j]]
+		if a.Weight != b.Weight {
+			return a.Weight < b.Weight
+		}
+		return codes[i] < codes[j]
+	})
+
+	defaultCode := siteMeta.DefaultLanguage
+	if _, ok := siteMeta.Languages[defaultCode]; !ok {
+		defaultCode = codes[0]
+		for _, code := range codes {
+			if siteMeta.Languages[code].BasePath == "" {
+				defaultCode = code
+				break
+			}
+		}
+	}
+
+	for _, code := range codes {
+		languageConfig := siteMeta.Languages[code]
+		lang := Language{Code: code, Name: languageConfig.Name, Title: languageConfig.Title, Default: code == defaultCode}
+		if lang.Name == "" {
+			lang.Name = code
+		}
+		if lang.Title == "" {
+			lang.Title = siteMeta.Title
+		}
+		if !lang.Default {
+			lang.BasePath = "/" + code
+		}
+		if languageConfig.BasePath != "" {
+			lang.BasePath = "/" + strings.Trim(languageConfig.BasePath, "/")
+		}
+		lang.Url = template.URL(siteMeta.BaseURL + lang.BasePath + "/")
+
+		if lang.Default {
+			siteLanguageList = append([]Language{lang}, siteLanguageList...)
+		} else {
+			siteLanguageList = append(siteLanguageList, lang)
+		}
+	}
+	siteLanguage = siteLanguageList[0]
+}
+
+// Switch siteMeta and the out directory over to a language before building or rendering its pages
+func useLanguage(lang Language) {
+	siteLanguage = lang
+	siteMeta = rootMeta
+	siteMeta.Title = lang.Title
+	siteMeta.BaseURL = rootMeta.BaseURL + lang.BasePath
+	sitePaths.Output = rootOutput + lang.BasePath
+}
+
+// The language of a content file from its suffix (page.fr.md) and its name without the suffix (page.md)
+// Files without a suffix for one of the site's languages are in the default language
+func fileLanguage(name string) (string, string) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	code := strings.TrimPrefix(filepath.Ext(base), ".")
+
+	if _, ok := rootMeta.Languages[code]; ok && code != "" {
+		return code, strings.TrimSuffix(base, "."+code) + ext
+	}
+	return siteLanguageList[0].Code, name
+}
+
+// Title case for directory names in the language being built
+func titleCase(text string) string {
+	tag, err := language.Parse(siteLanguage.Code)
+	if err != nil {
+		tag = language.Und
+	}
+	return cases.Title(tag).String(text)
+}
+
+// URLs for the site's own media (content/_media) and assets are the same in every language
+func mediaUrl(name string) string {
+	return rootMeta.BaseURL + "/media/" + strings.TrimPrefix(name, "/")
+}
+
+// Link every page to the same page in the other languages, pages are the same page when they have the same
+// TranslationKey (their source file without the language suffix, or their URL for generated pages)
+func linkTranslations(builds []languageBuild) {
+	if len(builds) < 2 {
+		return
+	}
+
+	versions := map[string][]Translation{}
+	for _, build := range builds {
+		for _, currentPage := range build.Pages {
+			versions[currentPage.TranslationKey] = append(versions[currentPage.TranslationKey], Translation{
+				Lang:         build.Language.Code,
+				LanguageName: build.Language.Name,
+				Title:        currentPage.Title,
+				Url:          currentPage.Url,
+			})
+		}
+	}
+
+	for _, build := range builds {
+		for i, currentPage := range build.Pages {
+			for _, translation := range versions[currentPage.TranslationKey] {
+				if translation.Lang != build.Language.Code {
+					build.Pages[i].Translations = append(build.Pages[i].Translations, translation)
+				}
+			}
+		}
+	}
+}
@@ -22,29 +22,44 @@ import (
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 	"golang.org/x/exp/slices"
-	newhtml "golang.org/x/net/html"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Title         string        `yaml:"title"`
-	Domain        string        `yaml:"domain"`
-	Email         string        `yaml:"email"`
-	Github        string        `yaml:"github"`
-	Facebook      string        `yaml:"facebook"`
-	Linkedin      string        `yaml:"linkedin"`
-	Twitter       string        `yaml:"twitter"`
-	Mastodon      string        `yaml:"mastodon"`
-	TemplateName  string        `yaml:"templatename"`
-	BaseURL       string        `yaml:"baseurl"`
-	Analytics     template.HTML `yaml:"analytics"`
-	DefaultOgType string        `yaml:"ogtype"`
-	Author        string        `yaml:"author"`
-	OgImage       string        `yaml:"ogimage"`
-	FavIconPath   string        `yaml:"faviconpath"`
+	Title           string                    `yaml:"title"`
+	Domain          string                    `yaml:"domain"`
+	Email           string                    `yaml:"email"`
+	Github          string                    `yaml:"github"`
+	Facebook        string                    `yaml:"facebook"`
+	Linkedin        string                    `yaml:"linkedin"`
+	Twitter         string                    `yaml:"twitter"`
+	Mastodon        string                    `yaml:"mastodon"`
+	TemplateName    string                    `yaml:"templatename"`
+	BaseURL         string                    `yaml:"baseurl"`
+	Analytics       template.HTML             `yaml:"analytics"`
+	DefaultOgType   string                    `yaml:"ogtype"`
+	Author          string                    `yaml:"author"`
+	OgImage         string                    `yaml:"ogimage"`
+	FavIconPath     string                    `yaml:"faviconpath"`
+	Sort            string                    `yaml:"sort"`
+	Paginate        int                       `yaml:"paginate"`
+	TagPages        bool                      `yaml:"tagpages"`
+	Related         int                       `yaml:"related"`
+	Fingerprint     bool                      `yaml:"fingerprint"`
+	TocMinLevel     int                       `yaml:"tocminlevel"`
+	TocMaxLevel     int                       `yaml:"tocmaxlevel"`
+	TocThreshold    int                       `yaml:"tocthreshold"`
+	HeadingAnchors  bool                      `yaml:"headinganchors"`
+	SummaryLength   int                       `yaml:"summarylength"`
+	GitInfo         bool                      `yaml:"gitinfo"`
+	DefaultLanguage string                    `yaml:"defaultlanguage"`
+	Languages       map[string]LanguageConfig `yaml:"languages"`
+	Permalinks      map[string]string         `yaml:"permalinks"`
+	UrlStyle        string                    `yaml:"urls"`
+	Sections        map[string]SectionConfig  `yaml:"sections"`
+	Acronyms        []string                  `yaml:"acronyms"`
 }
 
 type Redirects struct {
@@ -55,25 +70,45 @@ type Redirects struct {
 }
 
 type Page struct {
-	Title       string
-	Content     template.HTML
-	Path        string
-	SiteRoot    template.URL
-	Category    string
-	Section     string
-	Index       int
-	Nav         template.HTML
-	Intro       template.HTML
-	Analytics   template.HTML
-	Description template.HTML
-	OgType      string
-	Author      string
-	Url         template.URL
-	Date        string
-	OgImage     string
-	Tags        string
-	ChangeFreq  string
-	Priority    string
+	Title          string
+	Content        template.HTML
+	Path           string
+	SiteRoot       template.URL
+	Category       string
+	Section        string
+	Index          int
+	Nav            template.HTML
+	Intro          template.HTML
+	Analytics      template.HTML
+	Description    template.HTML
+	OgType         string
+	Author         string
+	Url            template.URL
+	Date           string
+	OgImage        string
+	Tags           string
+	ChangeFreq     string
+	Priority       string
+	Breadcrumbs    []Breadcrumb
+	Weight         int
+	Dir            string
+	Kind           string
+	Icon           string
+	Paginator      *Paginator
+	Includes       []string
+	TocEntries     []TocEntry
+	Summary        template.HTML
+	WordCount      int
+	ReadingTime    int
+	GitInfo        *GitInfo
+	LastMod        string
+	EditUrl        template.URL
+	HistoryUrl     template.URL
+	Authors        []Author
+	Source         string
+	Lang           string
+	TranslationKey string
+	Translations   []Translation
 }
 
 type Category struct {
@@ -84,6 +119,7 @@ type Category struct {
 
 type Section struct {
 	Title string
+	Icon  string
 	Index int
 	Crumb string
 }
@@ -204,22 +240,24 @@ func pageSection(workingFile string) Section {
 
 	return Section{
 		Index: index,
-		Title: strings.Replace(cases.Title(language.Und).String(section), "-", " ", 1),
+		Title: indexTitle(section, displayName(section)),
+		Icon:  siteIndexes[section].Icon,
 		Crumb: section,
 	}
 }
 
 /*	Content is split up by directories
-	Second-level navigation (shows on category pages) are stored in directories named _name (e.g. _work) and are called 'Categories'*/
+	Second-level navigation (shows on category pages) are stored in directories named _name (e.g. _work) and are called 'Categories'
+	Categories can be nested inside other categories to any depth, the Parent is the directory directly above*/
 func pageCategory(workingFile string) Category {
 	var category string
 	var parentCategory string
 	var categoryRe = regexp.MustCompile(`_(.+?)\/`)
 
 	categoryMatches := categoryRe.FindAllStringSubmatch(workingFile, -1)
-	if len(categoryMatches) == 2 {
-		parentCategory = categoryMatches[0][1]
-		category = categoryMatches[1][1]
+	if len(categoryMatches) >= 2 {
+		parentCategory = categoryMatches[len(categoryMatches)-2][1]
+		category = categoryMatches[len(categoryMatches)-1][1]
 	} else if len(categoryMatches) == 1 {
 		category = categoryMatches[0][1]
 	} else {
@@ -227,23 +265,15 @@ func pageCategory(workingFile string) Category {
 	}
 
 	return Category{
-		Title:  strings.Replace(cases.Title(language.Und).String(category), "-", "", 1),
+		Title:  indexTitle(indexKey(workingFile), displayName(category)),
 		Parent: parentCategory,
 		Crumb:  category,
 	}
 }
 
-func parsePage(workingFile string) Page {
-
-	outFile := strings.Replace(workingFile, "content", "out", 1)
-	outFile = strings.Replace(outFile, ".md", ".html", 1)
-
-	content, err := ioutil.ReadFile(workingFile)
-	if err != nil {
-		log.Fatal("FATAL: ", err, " workingFile: ", workingFile, " in parsePage(string,paths,config)->ReadFile")
-	}
-
-	md := goldmark.New(
+// The markdown converter shared by pages and _index.md files
+func newMarkdown() goldmark.Markdown {
+	return goldmark.New(
 		goldmark.WithExtensions(
 			extension.GFM,
 			meta.Meta,
@@ -252,6 +282,7 @@ func parsePage(workingFile string) Page {
 		),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
+			parser.WithAttribute(),
 		),
 		goldmark.WithRendererOptions(
 			html.WithHardWraps(),
@@ -259,20 +290,50 @@ func parsePage(workingFile string) Page {
 			html.WithUnsafe(),
 		),
 	)
+}
 
+// Render markdown to HTML, also returning its front matter and the headings for the table of contents
+func renderMarkdown(content []byte) (string, map[string]interface{}, []TocEntry) {
 	var buf bytes.Buffer
+	markdown := newMarkdown()
 	context := parser.NewContext()
-	if err := md.Convert(content, &buf, parser.WithContext(context)); err != nil {
+
+	doc := markdown.Parser().Parse(text.NewReader(content), parser.WithContext(context))
+	toc := tocEntries(doc, content)
+	if siteMeta.HeadingAnchors {
+		addHeadingAnchors(doc)
+	}
+
+	if err := markdown.Renderer().Render(&buf, content, doc); err != nil {
 		panic(err)
 	}
-	frontMatter := meta.Get(context)
+
+	return buf.String(), meta.Get(context), toc
+}
+
+func parsePage(workingFile string) Page {
+
 	relPath := strings.TrimPrefix(workingFile, sitePaths.Content)
+	_, sourcePath := fileLanguage(relPath)
+
+	content, err := ioutil.ReadFile(workingFile)
+	if err != nil {
+		log.Fatal("FATAL: ", err, " workingFile: ", workingFile, " in parsePage(string,paths,config)->ReadFile")
+	}
+
+	markdown, includes := renderShortcodes(string(content), workingFile)
+	content = []byte(markdown)
+
+	rendered, frontMatter, tocEntries := renderMarkdown(content)
+	if !tocEnabled(frontMatter) {
+		tocEntries = nil
+	}
 	pageCategory := pageCategory(relPath)
 	pageSection := pageSection(relPath)
 
 	var title string
 	if frontMatter["title"] == nil {
-		title = filepath.Base(outFile)
+		title = strings.TrimSuffix(filepath.Base(sourcePath), ".md") + ".html"
 	} else {
 		title = frontMatter["title"].(string)
 	}
@@ -284,18 +345,21 @@ func parsePage(workingFile string) Page {
 		pageIntro = frontMatter["intro"].(string)
 	}
 
+	summaryHtml := pageSummary(content, rendered, frontMatter)
+	words := wordCount(rendered)
+
 	var pageDescription string
 	if frontMatter["description"] == nil {
-		pageDescription = ""
+		pageDescription = summaryDescription(summaryHtml)
 	} else {
 		pageDescription = frontMatter["description"].(string)
 	}
 
 	var ogImage string
 	if frontMatter["ogimage"] == nil {
-		ogImage = siteMeta.BaseURL + "/media/" + siteMeta.OgImage
+		ogImage = mediaUrl(siteMeta.OgImage)
 	} else {
-		ogImage = siteMeta.BaseURL + "/media/" + frontMatter["ogimage"].(string)
+		ogImage = mediaUrl(frontMatter["ogimage"].(string))
 	}
 
 	var ogType string
@@ -309,6 +373,12 @@ func parsePage(workingFile string) Page {
 	if frontMatter["tags"] != nil {
 		if v, ok := frontMatter["tags"].(string); ok {
 			tags = v
+		} else if v, ok := frontMatter["tags"].([]interface{}); ok {
+			var tagList []string
+			for _, tag := range v {
+				tagList = append(tagList, fmt.Sprintf("%v", tag))
+			}
+			tags = strings.Join(tagList, ", ")
 		} else {
 			tags = fmt.Sprintf("%v", frontMatter["tags"])
 		}
@@ -323,46 +393,49 @@ func parsePage(workingFile string) Page {
 		}
 	}
 
-	outFile = strings.Replace(outFile, strconv.Itoa(pageSection.Index)+"_", "", 1)
-	outFile = strings.ReplaceAll(outFile, "/_", "/")
-
-	var categoryCrumb string
-	categoryCrumb = pageCategory.Crumb[strings.LastIndex(pageCategory.Crumb, " ")+1:]
-	categoryCrumb = strings.TrimRight(categoryCrumb, "]")
+	urlPath, outFile := pagePermalink(sourcePath, frontMatter, title, articleDate)
+	dirs := pageDirs(relPath)
 
-	pageUrl := siteMeta.BaseURL + strings.TrimPrefix(outFile, sitePaths.Output)
+	var canonUrl string
 
-	var pageNav string
-	if pageSection.Crumb == "" {
-		pageNav = ""
+	if outFile == sitePaths.Output+"/index.html" {
+		canonUrl = siteMeta.BaseURL
 	} else {
-		pageNav = "<a href=\"" + siteMeta.BaseURL + "\">Home</a> // <a href=\"" + siteMeta.BaseURL + "/" + pageSection.Crumb + "\">"
-		pageNav += strings.Replace(cases.Title(language.Und).String(pageSection.Crumb), "-", " ", 1) + "</a>"
-		pageNav += " // " + "<a href=\"" + siteMeta.BaseURL + "/" + pageSection.Crumb + "/" + categoryCrumb + "\">"
-		pageNav += strings.Replace(cases.Title(language.Und).String(categoryCrumb), "-", " ", 1) + "</a> // " + title
+		canonUrl = siteMeta.BaseURL + urlPath
 	}
 
-	var canonUrl string
+	kind := "article"
+	if outFile == sitePaths.Output+"/index.html" {
+		kind = "home"
+	}
 
-	if pageUrl == siteMeta.BaseURL+"/index.html" {
-		canonUrl = siteMeta.BaseURL
-	} else {
-		canonUrl = strings.Replace(pageUrl, ".html", "", 1)
+	authors := pageAuthors(frontMatter)
+	gitInfo, lastMod := pageGitInfo(workingFile, includes)
+	editUrl, historyUrl := gitLinks(workingFile)
+
+	var breadcrumbs []Breadcrumb
+	if len(dirs) > 0 {
+		breadcrumbs = append(dirBreadcrumbs(dirs), Breadcrumb{Title: title, Url: template.URL(canonUrl)})
 	}
 
 	return Page{
 		Title:       title,
-		Content:     template.HTML(buf.String()),
+		Content:     template.HTML(rendered),
 		Path:        outFile,
 		Category:    pageCategory.Title,
 		Section:     pageSection.Title,
 		Index:       pageSection.Index,
+		Dir:         strings.Join(dirs, "/"),
+		Kind:        kind,
 		SiteRoot:    template.URL(siteMeta.BaseURL),
-		Nav:         template.HTML(pageNav),
+		Nav:         template.HTML(renderBreadcrumbs(breadcrumbs)),
+		Breadcrumbs: breadcrumbs,
 		Intro:       template.HTML(pageIntro),
 		Description: template.HTML(pageDescription),
 		Analytics:   siteMeta.Analytics,
-		Author:      siteMeta.Author,
+		Author:      authorNames(authors),
+		Authors:     authors,
+		Source:      relPath,
 		OgType:      ogType,
 		Url:         template.URL(canonUrl),
 		Date:        articleDate,
@@ -370,6 +443,16 @@ func parsePage(workingFile string) Page {
 		Tags:        tags,
 		ChangeFreq:  "monthly",
 		Priority:    "0.5",
+		Weight:      metaInt(frontMatter, "weight"),
+		Includes:    includes,
+		TocEntries:  tocEntries,
+		Summary:     summaryHtml,
+		WordCount:   words,
+		ReadingTime: readingMinutes(words),
+		GitInfo:     gitInfo,
+		LastMod:     lastMod,
+		EditUrl:     editUrl,
+		HistoryUrl:  historyUrl,
 	}
 
 }
@@ -411,28 +494,32 @@ func copyFile(currentFile string, outPath string) {
 
 }
 
-func createPage(currentPage Page, sections []Section, topNav template.HTML) {
-	var templateFiles = []string{"header.html", "footer.html", "body.html", "base.html"}
-	var allPaths []string
-
-	for _, tmpl := range templateFiles {
-		allPaths = append(allPaths, "./"+sitePaths.TemplateSub+tmpl)
-	}
-
-	templates := template.Must(template.New("").ParseFiles(allPaths...))
-
-	Toc := addToc(string(currentPage.Content), string(currentPage.Title))
-
+func createPage(currentPage Page, site Site, links PageLinks) {
 	var processed bytes.Buffer
-	err := templates.ExecuteTemplate(&processed, "Base", struct{ CurrentPage, SiteMetaData, TopNav, Toc, Sections interface{} }{currentPage, siteMeta, topNav, Toc, sections})
+	err := siteTemplates.ExecuteTemplate(&processed, "Base", TemplateData{
+		CurrentPage:  currentPage,
+		SiteMetaData: siteMeta,
+		TopNav:       site.TopNav,
+		Toc:          renderToc(currentPage.TocEntries),
+		Sections:     site.Sections,
+		Menu:         activeMenu(site.Menu, currentPage.Url),
+		PrevPage:     links.Prev,
+		NextPage:     links.Next,
+		Related:      links.Related,
+		Site:         site,
+		Data:         site.Data,
+	})
 
 	if err != nil {
-		log.Fatal("FATAL: ", err, " Could not ExecuteTemplate for ", currentPage.Path, " in createPage(Page,Section,template.HTML,Config")
+		log.Fatal("FATAL: ", err, " Could not ExecuteTemplate for ", currentPage.Path, " in createPage(Page,Site,PageLinks")
 	}
 
+	recordAnchors(currentPage, processed.String())
+
+	createDirectory(filepath.Dir(currentPage.Path))
 	f, err := os.Create(currentPage.Path)
 	if err != nil {
-		log.Fatal("FATAL: ", err, " Could not create ", currentPage.Path, " in createPage(Page,Section,template.HTML,Config)")
+		log.Fatal("FATAL: ", err, " Could not create ", currentPage.Path, " in createPage(Page,Site,PageLinks)")
 	}
 	defer f.Close()
 
@@ -444,194 +531,19 @@ func createPage(currentPage Page, sections []Section, topNav template.HTML) {
 
 }
 
-func addToc(currentHtmlString string, currentTitle string) template.HTML {
-	tokenizer := newhtml.NewTokenizer(strings.NewReader(currentHtmlString))
-	var lastToc string
-	var lastLevel int
-	var tocLineItem string
-	var level int
-	var count int
-
-	lastLevel = 0
-	level = 0
-	count = 0
-
-	for {
-		tocLineItem = ""
-		tt := tokenizer.Next()
-
-		if tt == newhtml.ErrorToken {
-			if tokenizer.Err() == io.EOF {
-				break
-			}
-			log.Print("ERROR: tokenizer: ", tokenizer.Err(), " in addToc")
-			break
-		}
-
-		tag, hasAttr := tokenizer.TagName()
-
-		if hasAttr {
-			attrKey, attrValue, _ := tokenizer.TagAttr()
-
-			if string(attrKey) == "id" {
-				tokenizer.Next() //Need to move it one to get the text value
-				tocLinkItem := "<a href=\"#" + string(attrValue) + "\">" + string(tokenizer.Token().Data) + "</a>"
-
-				switch string(tag) {
-				case "h2": // H1 is always the site title so we start at H2
-					level = 1 // and set it to "1" to make it easier for me to understand later
-				case "h3":
-					level = 2
-				case "h4":
-					level = 3
-				case "h5":
-					level = 4
-				case "h6":
-					level = 5
-				}
-
-				lastLevel, tocLineItem = tocLevels(level, lastLevel, tocLinkItem)
-
-				if tocLineItem != "" {
-					lastToc = lastToc + tocLineItem
-					count++
-				}
-			}
-		}
-	}
-
-	var closeTags string
-
-	// We need to close out the lists (ul's and li's) that were opened
-	for i := lastLevel; i > 0; i-- {
-		closeTags = closeTags + "<!--cbs--></li></ul>"
-	}
-
-	if count >= 3 {
-		return template.HTML(lastToc + closeTags + "<br/>\n\n")
-	} else {
-		return template.HTML("")
-	}
-
-}
-
-func tocLevels(level int, lastLevel int, tocLinkItem string) (int, string) {
-	//adapted from https://stackoverflow.com/a/4912737
-	tocLineItem := ""
-	closeTags := ""
-
-	if level > lastLevel {
-		tocLineItem = "<ul>"
-	} else {
-		closeTags = strings.Repeat("</li></ul>", lastLevel-level)
-		closeTags = closeTags + "</li>"
-	}
-
-	tocLineItem = tocLineItem + closeTags + "<li>" + tocLinkItem
-	lastLevel = level
-
-	return lastLevel, tocLineItem + "\n"
-}
-
-func buildNavigation(sections []Section, categories []Category, pages []Page) (strings.Builder, []Page) {
-	var parentCategory string
-	var category string
-	var categoryCrumb string
-	var categoryPageHtml strings.Builder
-	var sectionPageHtml strings.Builder
-	var topNav strings.Builder
-
-	for _, currentSection := range sections {
-		if currentSection.Crumb == "" {
-			continue
-		}
-		sectionPageHtml.Reset()
-		sectionPageHtml.WriteString("<ul>")
-		topNav.WriteString("<li>\n<a href=\"" + siteMeta.BaseURL + "/" + currentSection.Crumb + "\">" + strings.Replace(currentSection.Crumb, "-", " ", 1) + "</a>\n<ul>\n")
-		for _, currentCategory := range categories {
-			categoryPageHtml.Reset()
-			parentCategory = currentCategory.Parent[strings.LastIndex(currentCategory.Parent, " ")+1:]
-			parentCategory = strings.TrimRight(parentCategory, "]")
-
-			if parentCategory == currentSection.Crumb {
-				categoryCrumb = currentCategory.Crumb[strings.LastIndex(currentCategory.Crumb, " ")+1:]
-				categoryCrumb = strings.TrimRight(categoryCrumb, "]")
-				category = currentCategory.Title[strings.LastIndex(currentCategory.Title, " ")+1:]
-				category = strings.Replace(strings.TrimRight(category, "]"), "-", " ", 1)
-
-				topNav.WriteString("<li><a href=\"" + siteMeta.BaseURL + "/" + currentSection.Crumb + "/" + categoryCrumb + "\">" + strings.Replace(categoryCrumb, "-", " ", 1) + "</a>\n<ul>\n")
-				sectionPageHtml.WriteString("<li><b><a href=\"" + siteMeta.BaseURL + "/" + currentSection.Crumb + "/" + categoryCrumb + "\">" + strings.Replace(category, "-", " ", 1) + "</a></b></li>\n")
-				categoryPageHtml.WriteString("<ul>\n")
-				sectionPageHtml.WriteString("<ul>\n")
-				for _, currentPage := range pages {
-					if currentPage.Category == currentCategory.Title {
-						categoryUrl := strings.Replace(siteMeta.BaseURL+"/"+currentSection.Crumb+"/"+categoryCrumb+"/"+currentPage.Path[strings.LastIndex(currentPage.Path, "/")+1:], ".html", "", 1)
-						categoryPageHtml.WriteString("<li><a href=\"" + categoryUrl + "\">" + currentPage.Title + "</a></li>\n")
-						sectionPageHtml.WriteString("<li><a href=\"" + categoryUrl + "\">" + currentPage.Title + "</a></li>\n")
-						topNav.WriteString("<li><a href=\"" + categoryUrl + "\">" + currentPage.Title + "</a></li>\n")
-					}
-
-				}
-
-				categoryPageHtml.WriteString("</ul>\n")
-				sectionPageHtml.WriteString("</ul>\n")
-				categoryNav := "<a href=\"" + siteMeta.BaseURL + "\">Home</a> // <a href=\"" + siteMeta.BaseURL + "/" + currentSection.Crumb + "\">" + strings.Replace(cases.Title(language.Und).String(currentSection.Crumb), "-", " ", 1) + "</a>" + " // " + strings.Replace(cases.Title(language.Und).String(categoryCrumb), "-", " ", 1)
-				categoryPageUrl := siteMeta.BaseURL + "/" + currentSection.Crumb + "/" + categoryCrumb + "/"
-
-				categoryPage := Page{
-					Title:       category,
-					Content:     template.HTML(categoryPageHtml.String()),
-					Path:        sitePaths.Output + "/" + currentSection.Crumb + "/" + categoryCrumb + "/index.html",
-					Category:    currentCategory.Title,
-					Section:     currentSection.Title,
-					Index:       currentSection.Index,
-					SiteRoot:    template.URL(siteMeta.BaseURL),
-					Nav:         template.HTML(categoryNav),
-					Analytics:   siteMeta.Analytics,
-					Description: template.HTML("Notes, ideas, and research I've captured about " + strings.ToLower(category) + "."),
-					OgType:      "website",
-					Url:         template.URL(categoryPageUrl),
-					OgImage:     siteMeta.BaseURL + "/media/" + siteMeta.OgImage,
-					ChangeFreq:  "weekly",
-					Priority:    "0.8",
-				}
-				pages = append(pages, categoryPage)
-				topNav.WriteString("</ul>\n</li>\n")
-			}
-
-		}
-		sectionPageHtml.WriteString("</ul>\n")
-
-		sectionNav := "<a href=\"" + siteMeta.BaseURL + "\">Home</a> // " + strings.Replace(cases.Title(language.Und).String(currentSection.Crumb), "-", " ", 1)
-		sectionPageUrl := siteMeta.BaseURL + "/" + currentSection.Crumb + "/"
-
-		sectionPage := Page{
-			Title:       currentSection.Title,
-			Content:     template.HTML(sectionPageHtml.String()),
-			Path:        sitePaths.Output + "/" + currentSection.Crumb + "/index.html",
-			Category:    currentSection.Title,
-			Section:     currentSection.Title,
-			Index:       currentSection.Index,
-			SiteRoot:    template.URL(siteMeta.BaseURL),
-			Nav:         template.HTML(sectionNav),
-			Analytics:   siteMeta.Analytics,
-			Description: template.HTML("Notes, ideas, and research I've captured in my " + strings.ToLower(currentSection.Title) + "."),
-			OgType:      "website",
-			Url:         template.URL(sectionPageUrl),
-			OgImage:     siteMeta.BaseURL + "/media/" + siteMeta.OgImage,
-			ChangeFreq:  "weekly",
-			Priority:    "1",
-		}
-		pages = append(pages, sectionPage)
-		topNav.WriteString("</ul></li>")
-	}
-	return topNav, pages
-}
-
 //Return a single sitemap item (for one url)
 func sitemap(currentPage Page) string {
 	siteMapItem := "  <url>\n"
 	siteMapItem += "    <loc>" + string(currentPage.Url) + "</loc>\n"
+	if currentPage.LastMod != "" {
+		siteMapItem += "    <lastmod>" + currentPage.LastMod + "</lastmod>\n"
+	}
+	if len(currentPage.Translations) > 0 {
+		siteMapItem += "    <xhtml:link rel=\"alternate\" hreflang=\"" + currentPage.Lang + "\" href=\"" + string(currentPage.Url) + "\"/>\n"
+		for _, translation := range currentPage.Translations {
+			siteMapItem += "    <xhtml:link rel=\"alternate\" hreflang=\"" + translation.Lang + "\" href=\"" + string(translation.Url) + "\"/>\n"
+		}
+	}
 	siteMapItem += "    <changefreq>" + currentPage.ChangeFreq + "</changefreq>\n"
 	siteMapItem += "    <priority>" + currentPage.Priority + "</priority>\n"
 	siteMapItem += "  </url>\n"
@@ -645,9 +557,13 @@ func createRedirects() {
 		toUrl := siteMeta.BaseURL + currentRedirect.To
 		filePath := sitePaths.Output + currentRedirect.From
 
+		if !claimOutput(filePath+"index.html", redirectFile+" ("+currentRedirect.From+")") {
+			continue
+		}
+
 		createDirectory(sitePaths.Output + "/" + currentRedirect.From)
 
-		html := "<html><head><meta http-equiv=\"refresh\" content=\"0;URL=" + toUrl + "\"></head><body><h1>Page has moved</h1><p>If not automatically redirected <a href=\"" + toUrl + "\">please click here</a>.</p></body></html>"
+		html := "<html><head><meta http-equiv=\"refresh\" content=\"0;URL=" + toUrl + "\"></head><body><h1>" + translate("pagemoved") + "</h1><p>" + translate("redirectnotice") + " <a href=\"" + toUrl + "\">" + translate("redirectlink") + "</a>.</p></body></html>"
 
 		file, err := os.Create(filePath + "index.html")
 		//log.Println("\t", filePath+"index.html")
@@ -664,50 +580,27 @@ func createRedirects() {
 	}
 }
 
-func main() {
-
-	loadSiteMeta()
-	setPaths()
-
+// Parse and build every page in one language, the default language also copies everything that isn't markdown
+func buildLanguage(lang Language, siteData map[string]interface{}) languageBuild {
 	var pages []Page
 	var sections []Section
-	var categories []Category
-
-	log.Println("Working Directory:\t", sitePaths.CurrentDirectory)
-	log.Println("Content Directory:\t", sitePaths.Content)
-	log.Println("Output Directory:\t", sitePaths.Output)
-	log.Println("Template Directory:\t", sitePaths.Template)
-	log.Println("Asset Directory:\t", sitePaths.Asset)
 
-	// To be safe, delete all the output directories and content
-	deleteErr := os.RemoveAll(sitePaths.Output)
-	if deleteErr != nil {
-		log.Fatalf("FATAL ERROR: %s", deleteErr)
+	useLanguage(lang)
+	if len(siteLanguageList) > 1 {
+		log.Println("Building", lang.Name, "pages into", sitePaths.Output)
 	}
-
-	// Copy over web assets
-	log.Println("Copying web assets")
-	filepath.WalkDir(sitePaths.Asset, func(currentFile string, info os.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			log.Fatalf("FATAL ERROR: %s", walkErr.Error())
-		}
-		assetPath := strings.Replace(currentFile, sitePaths.TemplateRoot, "/out", 1)
-
-		//log.Println("\t", assetPath)
-		if info.IsDir() {
-			createDirectory(assetPath)
-		} else {
-			copyFile(currentFile, assetPath)
-		}
-		return nil
-
-	})
+	loadAuthors()
+	loadIndexes()
 
 	filepath.WalkDir(sitePaths.Content, func(currentFile string, info os.DirEntry, err error) error {
 		if err != nil {
 			log.Fatalf("FATAL ERROR: %s", err.Error())
 		}
 
+		if isDataDirectory(currentFile, info) || isIncludesDirectory(currentFile, info) {
+			return filepath.SkipDir
+		}
+
 		// Skip if it's the .config directory or the site.yaml
 		// We'll still end up with a .git directory due to subdirectories existing but they'll all be empty - this should be fixed
 		if info.Name() == ".config" || info.Name() == "config.yaml" || info.Name() == ".github" || info.Name() == ".git" || info.Name() == "workflows" || info.Name() == "build-site.yaml" {
@@ -728,11 +621,17 @@ func main() {
 		outPath = strings.Replace(outPath, strconv.Itoa(sectionMeta.Index)+"_", "", 1)
 		outPath = strings.ReplaceAll(outPath, "/_", "/")
 
-		if info.IsDir() {
+		if info.IsDir() && lang.Default {
 			createDirectory(outPath)
 		}
 
-		if filepath.Ext(currentFile) == ".md" {
+		fileLang, name := fileLanguage(info.Name())
+		if name == "_index.md" {
+			// Read by loadIndexes()
+		} else if filepath.Ext(currentFile) == ".md" {
+			if fileLang != lang.Code {
+				return nil
+			}
 			pages = append(pages, parsePage(currentFile))
 
 			relFile := strings.TrimPrefix(currentFile, sitePaths.Content)
@@ -740,29 +639,99 @@ func main() {
 				sections = append(sections, pageSection(relFile))
 			}
 
-			if !slices.Contains(categories, pageCategory(relFile)) {
-				categories = append(categories, pageCategory(relFile))
+		} else if filepath.Ext(currentFile) != "" && lang.Default {
+			if claimOutput(outPath, "content"+strings.TrimPrefix(currentFile, sitePaths.Content)) {
+				copyFile(currentFile, outPath)
 			}
-
-		} else if filepath.Ext(currentFile) != "" {
-			copyFile(currentFile, outPath)
 		}
 		return nil
 
 	})
 
-	topNav, pages := buildNavigation(sections, categories, pages)
+	// Articles claim their URLs before the generated pages so a page that lost its URL to another one
+	// never shows up in the navigation, listings or sitemap
+	pages = claimPages(pages)
+	sortSections(sections)
+	topNav, menu, pages := buildNavigation(sections, pages)
+	pages = append(pages, buildTagPages(pages)...)
+	pages = append(pages, buildAuthorPages(pages)...)
+	pages = paginateHome(pages)
+	pages = claimPages(pages)
+
+	// Articles are the same page in another language when they come from the same file (without the language
+	// suffix), generated pages when they have the same URL
+	for i := range pages {
+		pages[i].Lang = lang.Code
+		if pages[i].Source != "" && pages[i].Paginator == nil {
+			_, pages[i].TranslationKey = fileLanguage(pages[i].Source)
+		} else {
+			pages[i].TranslationKey = strings.TrimPrefix(string(pages[i].Url), siteMeta.BaseURL)
+		}
+	}
+
+	site := buildSite(pages, sections, template.HTML(topNav.String()), menu)
+	site.Data = siteData
+	site.Language = lang
+	site.Languages = siteLanguageList
+
+	return languageBuild{Language: lang, Pages: pages, Site: site, PageLinks: buildPageLinks(pages)}
+}
+
+func main() {
+
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
+	loadSiteMeta()
+	setPaths()
+
+	log.Println("Working Directory:\t", sitePaths.CurrentDirectory)
+	log.Println("Content Directory:\t", sitePaths.Content)
+	log.Println("Output Directory:\t", sitePaths.Output)
+	log.Println("Template Directory:\t", sitePaths.Template)
+	log.Println("Asset Directory:\t", sitePaths.Asset)
+
+	// To be safe, delete all the output directories and content
+	deleteErr := os.RemoveAll(sitePaths.Output)
+	if deleteErr != nil {
+		log.Fatalf("FATAL ERROR: %s", deleteErr)
+	}
+
+	checkUrlStyle()
+	loadGitInfo()
+	loadLanguages()
+
+	// Copy over web assets
+	log.Println("Copying web assets")
+	loadTemplates()
+	loadI18n()
+	copyAssets()
+	siteData := loadData()
+
+	var builds []languageBuild
+	for _, lang := range siteLanguageList {
+		builds = append(builds, buildLanguage(lang, siteData))
+	}
+	linkTranslations(builds)
 
 	var siteMap string = ""
 
-	for _, currentPage := range pages {
-		createPage(currentPage, sections, template.HTML(topNav.String()))
-		siteMap += sitemap(currentPage)
+	for _, build := range builds {
+		useLanguage(build.Language)
+		for _, currentPage := range build.Pages {
+			createPage(currentPage, build.Site, build.PageLinks[currentPage.Path])
+			siteMap += sitemap(currentPage)
+		}
 	}
+	useLanguage(siteLanguageList[0])
 
 	// Create a sitemap.xml file
 
 	siteMapSchema := "<urlset xmlns:xsi=\"https://www.w3.org/2001/XMLSchema-instance\" xsi:schemaLocation=\"https://www.sitemaps.org/schemas/sitemap/0.9 https://www.sitemaps.org/schemas/sitemap/0.9/sitemap.xsd\" xmlns=\"https://www.sitemaps.org/schemas/sitemap/0.9\">\n"
+	if len(siteLanguageList) > 1 {
+		siteMapSchema = strings.Replace(siteMapSchema, ">\n", " xmlns:xhtml=\"http://www.w3.org/1999/xhtml\">\n", 1)
+	}
 	siteMapSchema += siteMap + "</urlset>"
 
 	file, err := os.Create(sitePaths.Output + "/sitemap.xml")
@@ -778,4 +747,5 @@ func main() {
 	}
 
 	createRedirects()
+	checkAnchors()
 }
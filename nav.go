@@ -0,0 +1,318 @@
+package main
+
+import (
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// A section or category directory, categories can be nested inside other categories to any depth
+type Node struct {
+	Crumb    string
+	Key      string // crumbs from the section down joined with "/", this is also the URL path
+	Section  Section
+	Parent   *Node
+	Children []*Node
+	Pages    []Page
+}
+
+// A single entry in the navigation tree, sections hold categories and categories hold pages and other categories
+type NavItem struct {
+	Title    string
+	Url      template.URL
+	Icon     string
+	Weight   int
+	Active   bool
+	Children []NavItem
+}
+
+// A single step in the trail from the home page to the current page
+type Breadcrumb struct {
+	Title string
+	Url   template.URL
+}
+
+// Everything handed to the "Base" template when a page is rendered
+type TemplateData struct {
+	CurrentPage  Page
+	SiteMetaData Config
+	TopNav       template.HTML
+	Toc          template.HTML
+	Sections     []Section
+	Menu         []NavItem
+	PrevPage     *Page
+	NextPage     *Page
+	Related      []Page
+	Site         Site
+	Data         map[string]interface{}
+}
+
+// Render breadcrumbs the way the original Nav string looked (Home // Section // Category // Title)
+func renderBreadcrumbs(crumbs []Breadcrumb) string {
+	var nav []string
+
+	for i, crumb := range crumbs {
+		if i == len(crumbs)-1 {
+			nav = append(nav, crumb.Title)
+		} else {
+			nav = append(nav, "<a href=\""+string(crumb.Url)+"\">"+crumb.Title+"</a>")
+		}
+	}
+
+	return strings.Join(nav, " // ")
+}
+
+// Return a copy of the menu with Active set on the current page and everything above it
+func activeMenu(menu []NavItem, currentUrl template.URL) []NavItem {
+	var items []NavItem
+	current := strings.TrimSuffix(string(currentUrl), "/")
+
+	for _, item := range menu {
+		item.Children = activeMenu(item.Children, currentUrl)
+		item.Active = strings.TrimSuffix(string(item.Url), "/") == current
+
+		for _, child := range item.Children {
+			if child.Active {
+				item.Active = true
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// The crumbs of the directories a content file sits in, starting with the section (e.g. [homelab-notes projects aws])
+// Files that aren't inside a section return nil
+func pageDirs(relFile string) []string {
+	var dirs []string
+	var sectionRe = regexp.MustCompile(`^\d{1,5}_(.+)$`)
+
+	for i, dir := range strings.Split(strings.Trim(filepath.Dir(relFile), "/"), "/") {
+		if i == 0 {
+			sectionMatches := sectionRe.FindStringSubmatch(dir)
+			if sectionMatches == nil {
+				return nil
+			}
+			dirs = append(dirs, sectionMatches[1])
+		} else {
+			dirs = append(dirs, strings.TrimPrefix(dir, "_"))
+		}
+	}
+
+	return dirs
+}
+
+// Breadcrumbs from Home down through every directory in dirs
+func dirBreadcrumbs(dirs []string) []Breadcrumb {
+	crumbs := []Breadcrumb{{Title: translate("home"), Url: template.URL(siteMeta.BaseURL)}}
+
+	for i, dir := range dirs {
+		crumbs = append(crumbs, Breadcrumb{
+			Title: indexTitle(strings.Join(dirs[:i+1], "/"), displayName(dir)),
+			Url:   template.URL(dirUrl(strings.Join(dirs[:i+1], "/"))),
+		})
+	}
+
+	return crumbs
+}
+
+// Put every page into the section and category directories it came from
+func buildTree(sections []Section, pages []Page) []*Node {
+	var tree []*Node
+	nodes := map[string]*Node{}
+
+	for _, currentSection := range sections {
+		if currentSection.Crumb == "" {
+			continue
+		}
+		node := &Node{Crumb: currentSection.Crumb, Key: currentSection.Crumb, Section: currentSection}
+		nodes[node.Key] = node
+		tree = append(tree, node)
+	}
+
+	for _, currentPage := range pages {
+		dirs := strings.Split(currentPage.Dir, "/")
+		parent := nodes[dirs[0]]
+		if currentPage.Dir == "" || parent == nil {
+			continue
+		}
+
+		for i := 1; i < len(dirs); i++ {
+			key := strings.Join(dirs[:i+1], "/")
+			if nodes[key] == nil {
+				nodes[key] = &Node{Crumb: dirs[i], Key: key, Section: parent.Section, Parent: parent}
+				parent.Children = append(parent.Children, nodes[key])
+			}
+			parent = nodes[key]
+		}
+		parent.Pages = append(parent.Pages, currentPage)
+	}
+
+	return tree
+}
+
+// A page on a listing along with the categories between the listing and the page
+type listingEntry struct {
+	Page  Page
+	Chain []*Node
+}
+
+// Build the top navigation, the menu and the listing pages for every section and category
+func buildNavigation(sections []Section, pages []Page) (strings.Builder, []NavItem, []Page) {
+	var topNav strings.Builder
+	var menu []NavItem
+	var listingPages []Page
+
+	for _, node := range buildTree(sections, pages) {
+		menu = append(menu, navigateNode(node, &topNav, &listingPages))
+	}
+
+	return topNav, menu, append(pages, listingPages...)
+}
+
+// Write the top navigation for a node and everything below it, add their listing pages and return the node's menu item
+func navigateNode(node *Node, topNav *strings.Builder, listingPages *[]Page) NavItem {
+	nodeUrl := dirUrl(node.Key)
+	label := nodeTitle(node)
+
+	item := NavItem{
+		Title:  label,
+		Url:    template.URL(nodeUrl),
+		Icon:   siteIndexes[node.Key].Icon,
+		Weight: siteIndexes[node.Key].Weight,
+	}
+
+	if node.Parent == nil {
+		item.Weight = sectionWeight(node.Section)
+		topNav.WriteString("<li>\n<a href=\"" + nodeUrl + "\">" + label + "</a>\n<ul>\n")
+	} else {
+		topNav.WriteString("<li><a href=\"" + nodeUrl + "\">" + label + "</a>\n<ul>\n")
+	}
+
+	sortPages(node.Pages, sortOrder(node.Key))
+	for _, currentPage := range node.Pages {
+		topNav.WriteString("<li><a href=\"" + string(currentPage.Url) + "\">" + currentPage.Title + "</a></li>\n")
+		item.Children = append(item.Children, NavItem{Title: currentPage.Title, Url: currentPage.Url, Weight: currentPage.Weight})
+	}
+
+	sortNodes(node.Children, sortOrder(node.Key))
+	for _, child := range node.Children {
+		item.Children = append(item.Children, navigateNode(child, topNav, listingPages))
+	}
+
+	if node.Parent == nil {
+		topNav.WriteString("</ul></li>")
+	} else {
+		topNav.WriteString("</ul>\n</li>\n")
+	}
+
+	entries := listingEntries(node)
+	var entryPages []Page
+	for _, entry := range entries {
+		entryPages = append(entryPages, entry.Page)
+	}
+
+	*listingPages = append(*listingPages, paginatedPages(listingPage(node), entryPages, func(pager *Paginator) template.HTML {
+		start := (pager.PageNumber - 1) * pager.PageSize
+		return template.HTML(renderListing(entries[start : start+len(pager.Items)]))
+	})...)
+
+	return item
+}
+
+// Every page under a node in listing order, its own pages first then each category below it
+func listingEntries(node *Node) []listingEntry {
+	var entries []listingEntry
+
+	for _, currentPage := range node.Pages {
+		entries = append(entries, listingEntry{Page: currentPage})
+	}
+	for _, child := range node.Children {
+		for _, entry := range listingEntries(child) {
+			entries = append(entries, listingEntry{Page: entry.Page, Chain: append([]*Node{child}, entry.Chain...)})
+		}
+	}
+
+	return entries
+}
+
+// Render listing entries as nested lists, each category gets a bold heading above its pages
+func renderListing(entries []listingEntry) string {
+	var listingHtml strings.Builder
+	var open []*Node
+
+	listingHtml.WriteString("<ul>\n")
+	for _, entry := range entries {
+		shared := 0
+		for shared < len(open) && shared < len(entry.Chain) && open[shared] == entry.Chain[shared] {
+			shared++
+		}
+		listingHtml.WriteString(strings.Repeat("</ul>\n", len(open)-shared))
+		for _, child := range entry.Chain[shared:] {
+			listingHtml.WriteString("<li><b><a href=\"" + dirUrl(child.Key) + "\">" + nodeTitle(child) + "</a></b></li>\n<ul>\n")
+		}
+		open = entry.Chain
+
+		listingHtml.WriteString("<li><a href=\"" + string(entry.Page.Url) + "\">" + entry.Page.Title + "</a>" + listingSummary(entry.Page) + "</li>\n")
+	}
+	listingHtml.WriteString(strings.Repeat("</ul>\n", len(open)))
+	listingHtml.WriteString("</ul>\n")
+
+	return listingHtml.String()
+}
+
+// The summary under a page's title on listing pages, nothing if it doesn't have one
+func listingSummary(currentPage Page) string {
+	if currentPage.Summary == "" {
+		return ""
+	}
+	return "<div class=\"summary\">" + string(currentPage.Summary) + "</div>"
+}
+
+// The title shown for a section or category in navigation and on listing pages
+func nodeTitle(node *Node) string {
+	return indexTitle(node.Key, displayName(node.Crumb))
+}
+
+// The generated index page for a section or category, the _index.md content goes above the list of pages
+func listingPage(node *Node) Page {
+	listingUrl := siteMeta.BaseURL + "/" + node.Key + "/"
+	crumbs := dirBreadcrumbs(strings.Split(node.Key, "/"))
+	crumbs[len(crumbs)-1].Url = template.URL(listingUrl)
+
+	page := Page{
+		Title:       nodeTitle(node),
+		Content:     siteIndexes[node.Key].Content,
+		Intro:       siteIndexes[node.Key].Intro,
+		Icon:        siteIndexes[node.Key].Icon,
+		TocEntries:  siteIndexes[node.Key].TocEntries,
+		Path:        sitePaths.Output + "/" + node.Key + "/index.html",
+		Section:     node.Section.Title,
+		Index:       node.Section.Index,
+		Dir:         node.Key,
+		SiteRoot:    template.URL(siteMeta.BaseURL),
+		Nav:         template.HTML(renderBreadcrumbs(crumbs)),
+		Breadcrumbs: crumbs,
+		Analytics:   siteMeta.Analytics,
+		OgType:      "website",
+		Url:         template.URL(listingUrl),
+		OgImage:     mediaUrl(siteMeta.OgImage),
+		ChangeFreq:  "weekly",
+	}
+
+	if node.Parent == nil {
+		page.Kind = "section"
+		page.Category = node.Section.Title
+		page.Description = template.HTML(indexDescription(node.Key, translate("sectiondescription", strings.ToLower(node.Section.Title))))
+		page.Priority = "1"
+	} else {
+		page.Kind = "category"
+		page.Category = page.Title
+		page.Description = template.HTML(indexDescription(node.Key, translate("categorydescription", strings.ToLower(page.Title))))
+		page.Priority = "0.8"
+	}
+
+	return page
+}
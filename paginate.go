@@ -0,0 +1,142 @@
+package main
+
+import (
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// One page of a listing, templates use it for the list itself, prev/next and page number links
+type Paginator struct {
+	PageNumber int
+	TotalPages int
+	PageSize   int
+	TotalItems int
+	Items      []Page
+	First      template.URL
+	Last       template.URL
+	Prev       template.URL
+	Next       template.URL
+	Numbers    []PagerLink
+}
+
+// A link to one page of a listing
+type PagerLink struct {
+	Number int
+	Url    template.URL
+	Active bool
+}
+
+// The URL of page number n of a listing, the first page is the listing itself (baseUrl ends in "/")
+func pagerUrl(baseUrl string, n int) template.URL {
+	if n == 1 {
+		return template.URL(baseUrl)
+	}
+	return template.URL(baseUrl + "page/" + strconv.Itoa(n) + "/")
+}
+
+// Split pages into chunks of `paginate` (from config.yaml) items, there is always at least one chunk
+// and with paginate unset everything goes on the first
+func paginate(pages []Page, baseUrl string) []*Paginator {
+	var pagers []*Paginator
+	size := siteMeta.Paginate
+	if size <= 0 || size > len(pages) {
+		size = len(pages)
+	}
+
+	total := 1
+	if size > 0 {
+		total = (len(pages) + size - 1) / size
+	}
+
+	for n := 1; n <= total; n++ {
+		pager := &Paginator{
+			PageNumber: n,
+			TotalPages: total,
+			PageSize:   size,
+			TotalItems: len(pages),
+			First:      pagerUrl(baseUrl, 1),
+			Last:       pagerUrl(baseUrl, total),
+		}
+		if size > 0 {
+			end := n * size
+			if end > len(pages) {
+				end = len(pages)
+			}
+			pager.Items = pages[(n-1)*size : end]
+		}
+		if n > 1 {
+			pager.Prev = pagerUrl(baseUrl, n-1)
+		}
+		if n < total {
+			pager.Next = pagerUrl(baseUrl, n+1)
+		}
+		for i := 1; i <= total; i++ {
+			pager.Numbers = append(pager.Numbers, PagerLink{Number: i, Url: pagerUrl(baseUrl, i), Active: i == n})
+		}
+		pagers = append(pagers, pager)
+	}
+
+	return pagers
+}
+
+// Copy a listing page once for each of its paginators, content(pager) returns the HTML list for that page
+// Only the first page has the listing's own content above the list, the rest just have their part of the list
+func paginatedPages(first Page, items []Page, content func(*Paginator) template.HTML) []Page {
+	var pages []Page
+	baseUrl := string(first.Url)
+
+	for _, pager := range paginate(items, baseUrl) {
+		page := first
+		page.Paginator = pager
+		page.Content = first.Content + content(pager)
+		if pager.PageNumber > 1 {
+			page.Content = content(pager)
+			page.TocEntries = nil
+			page.Url = pagerUrl(baseUrl, pager.PageNumber)
+			page.Path = strings.TrimSuffix(first.Path, "index.html") + "page/" + strconv.Itoa(pager.PageNumber) + "/index.html"
+		}
+		pages = append(pages, page)
+	}
+
+	return pages
+}
+
+// With `paginate` set the home page gets a list of every article, newest first, split up like the other listings
+func paginateHome(pages []Page) []Page {
+	var articles []Page
+	homeIndex := -1
+
+	if siteMeta.Paginate <= 0 {
+		return pages
+	}
+
+	for i, currentPage := range pages {
+		if currentPage.Kind == "article" {
+			articles = append(articles, currentPage)
+		} else if currentPage.Kind == "home" {
+			homeIndex = i
+		}
+	}
+	if homeIndex < 0 {
+		return pages
+	}
+	sort.SliceStable(articles, func(i, j int) bool {
+		return parseDate(articles[i].Date).After(parseDate(articles[j].Date))
+	})
+
+	home := pages[homeIndex]
+	home.Url = template.URL(siteMeta.BaseURL + "/")
+	homePages := paginatedPages(home, articles, func(pager *Paginator) template.HTML {
+		var entries []listingEntry
+		for _, article := range pager.Items {
+			entries = append(entries, listingEntry{Page: article})
+		}
+		return template.HTML(renderListing(entries))
+	})
+	homePages[0].Url = pages[homeIndex].Url
+
+	pages[homeIndex] = homePages[0]
+	return append(pages, homePages[1:]...)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"html/template"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func testPages(n int) []Page {
+	var pages []Page
+	for i := 1; i <= n; i++ {
+		pages = append(pages, Page{Title: "Page " + strconv.Itoa(i), Kind: "article", Url: template.URL("/notes/page-" + strconv.Itoa(i))})
+	}
+	return pages
+}
+
+func TestPaginate(t *testing.T) {
+	savedMeta := siteMeta
+	defer func() { siteMeta = savedMeta }()
+
+	tests := []struct {
+		name      string
+		paginate  int
+		pages     int
+		wantSizes []int
+	}{
+		{"unset", 0, 5, []int{5}},
+		{"even", 2, 4, []int{2, 2}},
+		{"remainder", 2, 5, []int{2, 2, 1}},
+		{"bigger than the list", 10, 3, []int{3}},
+		{"empty list", 2, 0, []int{0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			siteMeta.Paginate = test.paginate
+			pagers := paginate(testPages(test.pages), "/notes/")
+
+			var sizes []int
+			for _, pager := range pagers {
+				sizes = append(sizes, len(pager.Items))
+			}
+			if !reflect.DeepEqual(sizes, test.wantSizes) {
+				t.Fatalf("paginate(%d pages) with paginate %d = pages of %v, want %v", test.pages, test.paginate, sizes, test.wantSizes)
+			}
+			for i, pager := range pagers {
+				if pager.PageNumber != i+1 || pager.TotalPages != len(pagers) || pager.TotalItems != test.pages || len(pager.Numbers) != len(pagers) {
+					t.Errorf("pager %d = %+v", i+1, pager)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateLinks(t *testing.T) {
+	savedMeta := siteMeta
+	defer func() { siteMeta = savedMeta }()
+	siteMeta.Paginate = 2
+
+	pagers := paginate(testPages(5), "/notes/")
+	tests := []struct {
+		pager      *Paginator
+		wantPrev   template.URL
+		wantNext   template.URL
+		wantActive int
+	}{
+		{pagers[0], "", "/notes/page/2/", 1},
+		{pagers[1], "/notes/", "/notes/page/3/", 2},
+		{pagers[2], "/notes/page/2/", "", 3},
+	}
+
+	for _, test := range tests {
+		if test.pager.Prev != test.wantPrev || test.pager.Next != test.wantNext {
+			t.Errorf("page %d prev, next = %q, %q, want %q, %q", test.pager.PageNumber, test.pager.Prev, test.pager.Next, test.wantPrev, test.wantNext)
+		}
+		if test.pager.First != "/notes/" || test.pager.Last != "/notes/page/3/" {
+			t.Errorf("page %d first, last = %q, %q", test.pager.PageNumber, test.pager.First, test.pager.Last)
+		}
+		for _, number := range test.pager.Numbers {
+			if number.Active != (number.Number == test.wantActive) {
+				t.Errorf("page %d has page %d active = %v", test.pager.PageNumber, number.Number, number.Active)
+			}
+		}
+	}
+}
+
+func TestPaginatedPages(t *testing.T) {
+	savedMeta := siteMeta
+	defer func() { siteMeta = savedMeta }()
+	siteMeta.Paginate = 2
+
+	listing := Page{Title: "Notes", Url: "/notes/", Path: "out/notes/index.html", Content: "<p>intro</p>"}
+	pages := paginatedPages(listing, testPages(3), func(pager *Paginator) template.HTML {
+		return template.HTML("[list " + strconv.Itoa(pager.PageNumber) + "]")
+	})
+
+	tests := []struct {
+		wantUrl     template.URL
+		wantPath    string
+		wantContent template.HTML
+	}{
+		{"/notes/", "out/notes/index.html", "<p>intro</p>[list 1]"},
+		{"/notes/page/2/", "out/notes/page/2/index.html", "[list 2]"},
+	}
+
+	if len(pages) != len(tests) {
+		t.Fatalf("paginatedPages made %d pages, want %d", len(pages), len(tests))
+	}
+	for i, test := range tests {
+		if pages[i].Url != test.wantUrl || pages[i].Path != test.wantPath || pages[i].Content != test.wantContent {
+			t.Errorf("page %d = %q, %q, %q, want %q, %q, %q", i+1, pages[i].Url, pages[i].Path, pages[i].Content, test.wantUrl, test.wantPath, test.wantContent)
+		}
+	}
+}
+
+func TestPaginateHome(t *testing.T) {
+	savedMeta := siteMeta
+	defer func() { siteMeta = savedMeta }()
+
+	home := Page{Title: "Home", Kind: "home", Url: "/", Path: "out/index.html", Content: "<p>welcome</p>"}
+	tests := []struct {
+		name        string
+		paginate    int
+		wantPages   int
+		wantContent template.HTML
+	}{
+		{"unset", 0, 4, "<p>welcome</p>"},
+		{"set", 2, 5, "<p>welcome</p><ul>\n<li><a href=\"/notes/page-1\">Page 1</a></li>\n<li><a href=\"/notes/page-2\">Page 2</a></li>\n</ul>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			siteMeta.Paginate = test.paginate
+			pages := paginateHome(append([]Page{home}, testPages(3)...))
+			if len(pages) != test.wantPages {
+				t.Fatalf("paginateHome made %d pages, want %d", len(pages), test.wantPages)
+			}
+			if pages[0].Content != test.wantContent || pages[0].Url != "/" {
+				t.Errorf("home page = %q, %q, want %q", pages[0].Url, pages[0].Content, test.wantContent)
+			}
+		})
+	}
+}
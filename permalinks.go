@@ -0,0 +1,170 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Everything written to the out directory keyed by the URL it's served at, to catch two things writing the same file
+var siteOutputs = map[string]string{}
+
+// The URL path of a page from the language's base URL (e.g. /homelab-notes/projects/alpha) and the file it's written to
+// `url` in the front matter wins, then the section's pattern from `permalinks` in config.yaml, then the content path
+// with `slug` in place of the file name
+func pagePermalink(sourcePath string, frontMatter map[string]interface{}, title string, date string) (string, string) {
+	urlPath := contentUrlPath(sourcePath)
+	slug := path.Base(urlPath)
+	if frontSlug := strings.Trim(metaString(frontMatter, "slug"), "/"); frontSlug != "" {
+		slug = frontSlug
+		urlPath = path.Join(path.Dir(urlPath), slug)
+	}
+
+	dirs := pageDirs(sourcePath)
+	if frontUrl := metaString(frontMatter, "url"); frontUrl != "" {
+		urlPath = cleanUrlPath(frontUrl)
+	} else if len(dirs) > 0 && siteMeta.Permalinks[dirs[0]] != "" {
+		urlPath = expandPermalink(siteMeta.Permalinks[dirs[0]], sourcePath, dirs, slug, title, date)
+	}
+
+	switch {
+	case strings.HasSuffix(urlPath, "/"):
+		return urlPath, sitePaths.Output + urlPath + "index.html"
+	case siteMeta.UrlStyle == "pretty":
+		if path.Base(urlPath) == "index" {
+			urlPath = path.Dir(urlPath)
+		}
+		urlPath = strings.TrimSuffix(urlPath, "/") + "/"
+		return urlPath, sitePaths.Output + urlPath + "index.html"
+	case siteMeta.UrlStyle == "ugly":
+		return urlPath + ".html", sitePaths.Output + urlPath + ".html"
+	}
+	return urlPath, sitePaths.Output + urlPath + ".html"
+}
+
+// `urls` in config.yaml picks how pages are written and linked:
+//   - unset: /page.html linked as /page, for hosts that serve /page from page.html (like GitHub Pages)
+//   - pretty: /page/index.html linked as /page/, works on any static host
+//   - ugly: /page.html linked as /page.html, works on any static host
+func checkUrlStyle() {
+	if siteMeta.UrlStyle != "" && siteMeta.UrlStyle != "pretty" && siteMeta.UrlStyle != "ugly" {
+		log.Print("ERROR: unknown urls `", siteMeta.UrlStyle, "` in config.yaml, expected pretty or ugly, using the default")
+		siteMeta.UrlStyle = ""
+	}
+}
+
+// The URL of a section or category listing, with the trailing "/" when `urls` is set so static hosts serve the
+// directory's index.html without redirecting
+func dirUrl(key string) string {
+	if siteMeta.UrlStyle != "" {
+		return siteMeta.BaseURL + "/" + key + "/"
+	}
+	return siteMeta.BaseURL + "/" + key
+}
+
+// The URL path from where the file sits in content, without the section number, the underscores in front of
+// category directories and the .md, e.g. /1_homelab-notes/_projects/alpha.md is /homelab-notes/projects/alpha
+func contentUrlPath(sourcePath string) string {
+	var sectionRe = regexp.MustCompile(`^\d{1,5}_`)
+	segments := strings.Split(strings.TrimSuffix(strings.TrimPrefix(sourcePath, "/"), ".md"), "/")
+
+	for i, segment := range segments {
+		if i == 0 && len(segments) > 1 {
+			segment = sectionRe.ReplaceAllString(segment, "")
+		}
+		segments[i] = strings.TrimPrefix(segment, "_")
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// Fill in a permalink pattern like /:section/:year/:slug/, the tokens are :section, :categories (every category
+// below the section), :category (the closest one), :year, :month, :day, :slug, :title and :filename
+func expandPermalink(pattern string, sourcePath string, dirs []string, slug string, title string, date string) string {
+	var year, month, day string
+	if strings.Contains(pattern, ":year") || strings.Contains(pattern, ":month") || strings.Contains(pattern, ":day") {
+		if parsed := parseDate(date); parsed.IsZero() {
+			log.Print("ERROR: ", sourcePath, " has no date for its permalink `", pattern, "`, leaving the date out of its URL")
+		} else {
+			year = strconv.Itoa(parsed.Year())
+			month = parsed.Format("01")
+			day = parsed.Format("02")
+		}
+	}
+
+	category := ""
+	if len(dirs) > 1 {
+		category = dirs[len(dirs)-1]
+	}
+
+	expanded := strings.NewReplacer(
+		":section", dirs[0],
+		":categories", strings.Join(dirs[1:], "/"),
+		":category", category,
+		":year", year,
+		":month", month,
+		":day", day,
+		":slug", slug,
+		":title", slugify(title),
+		":filename", path.Base(contentUrlPath(sourcePath)),
+	).Replace(pattern)
+
+	return cleanUrlPath(expanded)
+}
+
+// Start a URL path with "/" and squash the "//"s left by empty tokens, a trailing "/" is kept
+func cleanUrlPath(urlPath string) string {
+	cleaned := path.Clean("/" + urlPath)
+	if strings.HasSuffix(urlPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// Record that source writes the file at outPath, logging an error and returning false if something else already does
+// /page.html and /page/index.html are served at the same URL so they count as the same file
+func claimOutput(outPath string, source string) bool {
+	key := strings.TrimPrefix(outPath, rootOutput)
+	key = strings.TrimSuffix(key, "/index.html")
+	key = strings.TrimSuffix(key, ".html")
+	key = strings.TrimSuffix(key, "/")
+
+	if other, ok := siteOutputs[key]; ok && other != source {
+		log.Print("ERROR: ", source, " and ", other, " are both written to ", strings.TrimPrefix(outPath, rootOutput), ", set a different slug or url for one of them")
+		return false
+	}
+	siteOutputs[key] = source
+	return true
+}
+
+// The pages that got their URL, a page whose URL is already taken by something else is left out (and logged)
+// When the first page of a listing loses its URL the rest of its pages are left out too, so there's no /page/2/
+// without the listing it belongs to
+func claimPages(pages []Page) []Page {
+	var claimed []Page
+	lostListings := map[template.URL]bool{}
+
+	for _, currentPage := range pages {
+		if currentPage.Paginator != nil && lostListings[currentPage.Paginator.First] {
+			continue
+		}
+		if claimOutput(currentPage.Path, pageOrigin(currentPage)) {
+			claimed = append(claimed, currentPage)
+		} else if currentPage.Paginator != nil && currentPage.Paginator.PageNumber == 1 && currentPage.Paginator.TotalPages > 1 {
+			lostListings[currentPage.Paginator.First] = true
+			log.Print("ERROR: leaving out the other ", currentPage.Paginator.TotalPages-1, " pages of ", pageOrigin(currentPage), " as well")
+		}
+	}
+	return claimed
+}
+
+// What wrote a page for collision errors, its content file or the kind of page it is
+func pageOrigin(currentPage Page) string {
+	if currentPage.Source != "" {
+		return "content" + currentPage.Source
+	}
+	return "the " + currentPage.Kind + " page " + string(currentPage.Url)
+}
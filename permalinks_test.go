@@ -0,0 +1,166 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContentUrlPath(t *testing.T) {
+	tests := []struct {
+		sourcePath string
+		want       string
+	}{
+		{"/1_homelab-notes/_projects/alpha.md", "/homelab-notes/projects/alpha"},
+		{"1_homelab-notes/setup.md", "/homelab-notes/setup"},
+		{"/about.md", "/about"},
+		{"/2024_notes.md", "/2024_notes"},
+		{"/1_notes/_2_drafts/a.md", "/notes/2_drafts/a"},
+	}
+
+	for _, test := range tests {
+		if got := contentUrlPath(test.sourcePath); got != test.want {
+			t.Errorf("contentUrlPath(%q) = %q, want %q", test.sourcePath, got, test.want)
+		}
+	}
+}
+
+func TestExpandPermalink(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		dirs    []string
+		date    string
+		want    string
+	}{
+		{"section and slug", "/:section/:slug/", []string{"notes", "projects"}, "", "/notes/alpha-one/"},
+		{"categories", "/:section/:categories/:slug", []string{"notes", "projects", "lab"}, "", "/notes/projects/lab/alpha-one"},
+		{"closest category", "/:category/:slug", []string{"notes", "projects", "lab"}, "", "/lab/alpha-one"},
+		{"no categories", "/:section/:categories/:category/:slug", []string{"notes"}, "", "/notes/alpha-one"},
+		{"date", "/:year/:month/:day/:slug/", []string{"notes"}, "2024-03-09", "/2024/03/09/alpha-one/"},
+		{"missing date", "/:section/:year/:slug", []string{"notes"}, "", "/notes/alpha-one"},
+		{"title and filename", "/:title/:filename", []string{"notes"}, "", "/alpha-release-notes/alpha"},
+		{"no leading slash", ":section/:slug", []string{"notes"}, "", "/notes/alpha-one"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := expandPermalink(test.pattern, "/1_notes/_projects/alpha.md", test.dirs, "alpha-one", "Alpha: Release Notes", test.date)
+			if got != test.want {
+				t.Errorf("expandPermalink(%q) = %q, want %q", test.pattern, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCleanUrlPath(t *testing.T) {
+	tests := []struct {
+		urlPath string
+		want    string
+	}{
+		{"/notes/alpha", "/notes/alpha"},
+		{"notes/alpha", "/notes/alpha"},
+		{"/notes//alpha/", "/notes/alpha/"},
+		{"/notes/../alpha", "/alpha"},
+		{"", "/"},
+		{"/", "/"},
+		{"//", "/"},
+	}
+
+	for _, test := range tests {
+		if got := cleanUrlPath(test.urlPath); got != test.want {
+			t.Errorf("cleanUrlPath(%q) = %q, want %q", test.urlPath, got, test.want)
+		}
+	}
+}
+
+func TestPagePermalink(t *testing.T) {
+	savedMeta, savedPaths := siteMeta, sitePaths
+	defer func() { siteMeta, sitePaths = savedMeta, savedPaths }()
+	sitePaths.Output = "out"
+
+	tests := []struct {
+		name        string
+		urlStyle    string
+		sourcePath  string
+		frontMatter map[string]interface{}
+		wantUrl     string
+		wantOutPath string
+	}{
+		{"default", "", "/1_notes/alpha.md", nil, "/notes/alpha", "out/notes/alpha.html"},
+		{"index", "", "/1_notes/index.md", nil, "/notes/index", "out/notes/index.html"},
+		{"pretty", "pretty", "/1_notes/alpha.md", nil, "/notes/alpha/", "out/notes/alpha/index.html"},
+		{"ugly", "ugly", "/1_notes/alpha.md", nil, "/notes/alpha.html", "out/notes/alpha.html"},
+		{"pretty index", "pretty", "/1_notes/index.md", nil, "/notes/", "out/notes/index.html"},
+		{"slug", "", "/1_notes/alpha.md", map[string]interface{}{"slug": "first"}, "/notes/first", "out/notes/first.html"},
+		{"url", "", "/1_notes/alpha.md", map[string]interface{}{"url": "/start/"}, "/start/", "out/start/index.html"},
+		{"permalink", "", "/1_blog/post.md", nil, "/blog/2024/post", "out/blog/2024/post.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			siteMeta = Config{UrlStyle: test.urlStyle, Permalinks: map[string]string{"blog": "/:section/:year/:slug"}}
+			url, outPath := pagePermalink(test.sourcePath, test.frontMatter, "Title", "2024-01-02")
+			if url != test.wantUrl || outPath != test.wantOutPath {
+				t.Errorf("pagePermalink(%q) = %q, %q, want %q, %q", test.sourcePath, url, outPath, test.wantUrl, test.wantOutPath)
+			}
+		})
+	}
+}
+
+func TestClaimOutput(t *testing.T) {
+	savedOutput := rootOutput
+	defer func() { rootOutput, siteOutputs = savedOutput, map[string]string{} }()
+	rootOutput = "out"
+
+	tests := []struct {
+		name    string
+		outPath string
+		source  string
+		want    bool
+	}{
+		{"first claim", "out/notes/alpha.html", "a.md", true},
+		{"same source again", "out/notes/alpha.html", "a.md", true},
+		{"same file", "out/notes/alpha.html", "b.md", false},
+		{"pretty and default collide", "out/notes/alpha/index.html", "b.md", false},
+		{"different file", "out/notes/beta.html", "b.md", true},
+		{"home", "out/index.html", "home", true},
+		{"home again", "out/index.html", "c.md", false},
+	}
+
+	siteOutputs = map[string]string{}
+	for _, test := range tests {
+		if got := claimOutput(test.outPath, test.source); got != test.want {
+			t.Errorf("%s: claimOutput(%q, %q) = %v, want %v", test.name, test.outPath, test.source, got, test.want)
+		}
+	}
+}
+
+func TestClaimPages(t *testing.T) {
+	savedOutput := rootOutput
+	defer func() { rootOutput, siteOutputs = savedOutput, map[string]string{} }()
+	rootOutput = "out"
+
+	notes := &Paginator{PageNumber: 1, TotalPages: 2, First: "/notes/"}
+	other := &Paginator{PageNumber: 1, TotalPages: 2, First: "/other/"}
+	pages := []Page{
+		{Title: "start", Kind: "article", Source: "/1_start.md", Path: "out/notes/index.html"},
+		{Title: "alpha", Kind: "article", Source: "/1_notes/alpha.md", Path: "out/notes/alpha.html"},
+		{Title: "copy of alpha", Kind: "article", Source: "/1_notes/beta.md", Path: "out/notes/alpha/index.html"},
+		{Title: "notes 1", Kind: "section", Path: "out/notes/index.html", Paginator: notes},
+		{Title: "notes 2", Kind: "section", Path: "out/notes/page/2/index.html", Paginator: &Paginator{PageNumber: 2, TotalPages: 2, First: "/notes/"}},
+		{Title: "other 1", Kind: "section", Path: "out/other/index.html", Paginator: other},
+		{Title: "other 2", Kind: "section", Path: "out/other/page/2/index.html", Paginator: &Paginator{PageNumber: 2, TotalPages: 2, First: "/other/"}},
+		{Title: "alpha again", Kind: "article", Source: "/1_notes/alpha.md", Path: "out/notes/alpha.html"},
+	}
+
+	siteOutputs = map[string]string{}
+	var got []string
+	for _, currentPage := range claimPages(pages) {
+		got = append(got, currentPage.Title)
+	}
+
+	want := []string{"start", "alpha", "other 1", "other 2", "alpha again"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("claimPages() kept %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Links from an article to its neighbours in the same category and to similar articles elsewhere
+type PageLinks struct {
+	Prev    *Page
+	Next    *Page
+	Related []Page
+}
+
+// How many related pages are worked out for each article when `related` isn't set in config.yaml
+const defaultRelated = 5
+
+// Work out the neighbours and related pages for every article, keyed by the article's Path
+func buildPageLinks(pages []Page) map[string]PageLinks {
+	links := map[string]PageLinks{}
+	categories := map[string][]Page{}
+	var articles []Page
+
+	for _, currentPage := range pages {
+		if currentPage.Kind == "article" {
+			articles = append(articles, currentPage)
+			if currentPage.Dir != "" {
+				categories[currentPage.Dir] = append(categories[currentPage.Dir], currentPage)
+			}
+		}
+	}
+
+	// Neighbours are in the same order as the category listing
+	for dir, categoryPages := range categories {
+		sortPages(categoryPages, sortOrder(dir))
+		for i := range categoryPages {
+			link := links[categoryPages[i].Path]
+			if i > 0 {
+				link.Prev = &categoryPages[i-1]
+			}
+			if i < len(categoryPages)-1 {
+				link.Next = &categoryPages[i+1]
+			}
+			links[categoryPages[i].Path] = link
+		}
+	}
+
+	for _, currentPage := range articles {
+		link := links[currentPage.Path]
+		link.Related = relatedPages(currentPage, articles)
+		links[currentPage.Path] = link
+	}
+
+	return links
+}
+
+// Other articles ranked by shared tags (2 points each) and being in the same category (1 point), newest first on ties
+// Setting `related` to a negative number turns related pages off
+func relatedPages(currentPage Page, articles []Page) []Page {
+	var related []Page
+	scores := map[string]int{}
+	limit := siteMeta.Related
+	if limit == 0 {
+		limit = defaultRelated
+	} else if limit < 0 {
+		return nil
+	}
+
+	currentTags := map[string]bool{}
+	for _, tag := range pageTags(currentPage) {
+		currentTags[strings.ToLower(tag)] = true
+	}
+
+	for _, otherPage := range articles {
+		if otherPage.Path == currentPage.Path {
+			continue
+		}
+		score := 0
+		for _, tag := range pageTags(otherPage) {
+			if currentTags[strings.ToLower(tag)] {
+				score += 2
+			}
+		}
+		if currentPage.Dir != "" && otherPage.Dir == currentPage.Dir {
+			score++
+		}
+		if score > 0 {
+			scores[otherPage.Path] = score
+			related = append(related, otherPage)
+		}
+	}
+
+	sort.SliceStable(related, func(i, j int) bool {
+		if scores[related[i].Path] != scores[related[j].Path] {
+			return scores[related[i].Path] > scores[related[j].Path]
+		}
+		return parseDate(related[i].Date).After(parseDate(related[j].Date))
+	})
+
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related
+}
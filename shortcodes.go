@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A shortcode in markdown, e.g. {{< note type="warning" >}}Back up first{{< /note >}}
+// Templates in shortcodes/<name>.html get one of these
+type Shortcode struct {
+	Name   string
+	Params map[string]string // key="value" params
+	Args   []string          // params without a key, in order
+	Inner  string            // raw markdown between the opening and closing tags, "" when there's no closing tag
+	File   string            // the content file the shortcode is in
+}
+
+// {{< name params >}}, {{< /name >}} and {{< name params />}}
+var shortcodeRe = regexp.MustCompile(`\{\{<\s*(/?)\s*([\w-]+)(.*?)(/?)\s*>\}\}`)
+
+// key="value", key=value, "value" or value, quoted values can have \" in them
+var shortcodeParamRe = regexp.MustCompile(`([\w-]+)=("(?:[^"\\]|\\.)*"|\S+)|("(?:[^"\\]|\\.)*"|\S+)`)
+
+// {{</* name */>}} is written out as {{< name >}} for documenting shortcodes
+var shortcodeEscapeRe = regexp.MustCompile(`\{\{</\*(.*?)\*/>\}\}`)
+
+// A named param, or a positional one when key is an int, e.g. {{.Get "src"}} or {{.Get 0}}
+func (shortcode Shortcode) Get(key interface{}) string {
+	switch k := key.(type) {
+	case int:
+		if k >= 0 && k < len(shortcode.Args) {
+			return shortcode.Args[k]
+		}
+	case string:
+		return shortcode.Params[k]
+	}
+	return ""
+}
+
+// Replace the shortcodes in a content file's markdown with their HTML before it goes through goldmark,
+// also returns every file it includes
+func renderShortcodes(markdown string, workingFile string) (string, []string) {
+	includes := &includeStack{files: []string{filepath.Clean(workingFile)}}
+	return expandShortcodes(markdown, workingFile, includes), includes.included
+}
+
+func expandShortcodes(markdown string, workingFile string, includes *includeStack) string {
+	var out strings.Builder
+
+	for {
+		loc := shortcodeRe.FindStringSubmatchIndex(markdown)
+		if loc == nil {
+			break
+		}
+		out.WriteString(unescapeShortcodes(markdown[:loc[0]]))
+
+		tag := markdown[loc[0]:loc[1]]
+		closing := markdown[loc[2]:loc[3]] == "/"
+		name := markdown[loc[4]:loc[5]]
+		params := strings.TrimSpace(markdown[loc[6]:loc[7]])
+		selfClosing := markdown[loc[8]:loc[9]] == "/"
+		markdown = markdown[loc[1]:]
+
+		if closing {
+			log.Print("ERROR: ", tag, " has no opening tag in ", workingFile)
+			out.WriteString(tag)
+			continue
+		}
+
+		shortcode := Shortcode{Name: name, Params: map[string]string{}, File: workingFile}
+		parseShortcodeParams(&shortcode, params)
+
+		if !selfClosing {
+			if end, after := closingShortcode(markdown, name); end >= 0 {
+				shortcode.Inner = expandShortcodes(markdown[:end], workingFile, includes)
+				markdown = markdown[after:]
+			}
+		}
+
+		out.WriteString(executeShortcode(shortcode, tag, includes))
+	}
+	out.WriteString(unescapeShortcodes(markdown))
+
+	return out.String()
+}
+
+// Find the {{< /name >}} that closes a shortcode, skipping over nested shortcodes with the same name
+// Returns where it starts and ends, or -1 if there isn't one
+func closingShortcode(markdown string, name string) (int, int) {
+	open := 0
+
+	for _, loc := range shortcodeRe.FindAllStringSubmatchIndex(markdown, -1) {
+		if markdown[loc[4]:loc[5]] != name || markdown[loc[8]:loc[9]] == "/" {
+			continue
+		}
+		if markdown[loc[2]:loc[3]] != "/" {
+			open++
+		} else if open > 0 {
+			open--
+		} else {
+			return loc[0], loc[1]
+		}
+	}
+
+	return -1, -1
+}
+
+func parseShortcodeParams(shortcode *Shortcode, params string) {
+	for _, match := range shortcodeParamRe.FindAllStringSubmatch(params, -1) {
+		if match[1] != "" {
+			shortcode.Params[match[1]] = unquote(match[2])
+		} else {
+			shortcode.Args = append(shortcode.Args, unquote(match[3]))
+		}
+	}
+}
+
+func unquote(value string) string {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return strings.Trim(value, `"`)
+}
+
+func unescapeShortcodes(markdown string) string {
+	return shortcodeEscapeRe.ReplaceAllString(markdown, "{{<$1>}}")
+}
+
+// The HTML for a shortcode from its template, unknown shortcodes are left as they are
+func executeShortcode(shortcode Shortcode, tag string, includes *includeStack) string {
+	if shortcode.Name == "include" {
+		return includeFile(shortcode, includes)
+	}
+
+	name := "shortcodes/" + shortcode.Name + ".html"
+	if siteTemplates == nil || siteTemplates.Lookup(name) == nil {
+		log.Print("ERROR: no template for shortcode `", shortcode.Name, "` (", name, ") in ", shortcode.File)
+		return tag
+	}
+
+	var processed bytes.Buffer
+	if err := siteTemplates.ExecuteTemplate(&processed, name, shortcode); err != nil {
+		log.Print("ERROR: ", err, " in shortcode `", shortcode.Name, "` in ", shortcode.File)
+		return tag
+	}
+	return strings.TrimSpace(processed.String())
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+)
+
+func TestExpandShortcodes(t *testing.T) {
+	siteTemplates = template.Must(template.New("").Funcs(templateFuncs()).New("shortcodes/wrap.html").Parse(`[{{.Get 0}}|{{.Inner}}]`))
+	defer func() { siteTemplates = nil }()
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"no shortcodes", "plain *markdown*", "plain *markdown*"},
+		{"self closing", "a {{< wrap x />}} b", "a [x|] b"},
+		{"no closing tag", "a {{< wrap x >}} b", "a [x|] b"},
+		{"inner", "{{< wrap x >}}hello{{< /wrap >}}", "[x|hello]"},
+		{"no spaces", "{{<wrap x>}}hello{{</wrap>}}", "[x|hello]"},
+		{"nested same name", "{{< wrap 1 >}}a{{< wrap 2 >}}b{{< /wrap >}}c{{< /wrap >}}", "[1|a[2|b]c]"},
+		{"siblings", "{{< wrap 1 >}}a{{< /wrap >}} {{< wrap 2 >}}b{{< /wrap >}}", "[1|a] [2|b]"},
+		{"escaped", "{{</* wrap x */>}}", "{{< wrap x >}}"},
+		{"unknown shortcode", "{{< missing >}}", "{{< missing >}}"},
+		{"stray closing tag", "a {{< /wrap >}}", "a {{< /wrap >}}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := expandShortcodes(test.markdown, "test.md", &includeStack{})
+			if got != test.want {
+				t.Errorf("expandShortcodes(%q) = %q, want %q", test.markdown, got, test.want)
+			}
+		})
+	}
+}
+
+func TestClosingShortcode(t *testing.T) {
+	tests := []struct {
+		name      string
+		markdown  string
+		shortcode string
+		wantInner string
+		wantFound bool
+	}{
+		{"simple", "inner{{< /note >}}after", "note", "inner", true},
+		{"missing", "inner without a closing tag", "note", "", false},
+		{"other name", "a{{< /details >}}b{{< /note >}}", "note", "a{{< /details >}}b", true},
+		{"nested", "a{{< note >}}b{{< /note >}}c{{< /note >}}", "note", "a{{< note >}}b{{< /note >}}c", true},
+		{"self closing doesn't nest", "a{{< note />}}b{{< /note >}}", "note", "a{{< note />}}b", true},
+		{"unbalanced", "a{{< note >}}b{{< /note >}}", "note", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			end, after := closingShortcode(test.markdown, test.shortcode)
+			if found := end >= 0; found != test.wantFound {
+				t.Fatalf("closingShortcode(%q) found = %v, want %v", test.markdown, found, test.wantFound)
+			}
+			if end >= 0 && (test.markdown[:end] != test.wantInner || after <= end) {
+				t.Errorf("closingShortcode(%q) inner = %q (after %d), want %q", test.markdown, test.markdown[:end], after, test.wantInner)
+			}
+		})
+	}
+}
+
+func TestParseShortcodeParams(t *testing.T) {
+	tests := []struct {
+		params     string
+		wantParams map[string]string
+		wantArgs   []string
+	}{
+		{``, map[string]string{}, nil},
+		{`src="a b.jpg" alt=photo`, map[string]string{"src": "a b.jpg", "alt": "photo"}, nil},
+		{`"first one" second`, map[string]string{}, []string{"first one", "second"}},
+		{`warning title="Read \"this\""`, map[string]string{"title": `Read "this"`}, []string{"warning"}},
+	}
+
+	for _, test := range tests {
+		shortcode := Shortcode{Params: map[string]string{}}
+		parseShortcodeParams(&shortcode, test.params)
+		if !reflect.DeepEqual(shortcode.Params, test.wantParams) || !reflect.DeepEqual(shortcode.Args, test.wantArgs) {
+			t.Errorf("parseShortcodeParams(%q) = %v %q, want %v %q", test.params, shortcode.Params, shortcode.Args, test.wantParams, test.wantArgs)
+		}
+	}
+}
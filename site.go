@@ -0,0 +1,67 @@
+package main
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// Site wide navigation and page collections, available to every template as .Site
+type Site struct {
+	Pages           []Page            // every article
+	AllPages        []Page            // articles, the home page and generated listing pages
+	Recent          []Page            // articles with a date, newest first
+	PagesBySection  map[string][]Page // articles keyed by section (e.g. "homelab-notes")
+	PagesByCategory map[string][]Page // articles keyed by their directory (e.g. "homelab-notes/projects")
+	PagesByTag      map[string][]Page // articles keyed by tag slug (e.g. "getting-started")
+	Tags            []Tag
+	Authors         []Author
+	Sections        []Section
+	Menu            []NavItem
+	TopNav          template.HTML
+	Data            map[string]interface{} // data files, see loadData()
+	Language        Language               // the language being rendered
+	Languages       []Language             // every language the site is built in, default first
+}
+
+// Gather the collections once all the pages have been built
+func buildSite(pages []Page, sections []Section, topNav template.HTML, menu []NavItem) Site {
+	site := Site{
+		AllPages:        pages,
+		PagesBySection:  map[string][]Page{},
+		PagesByCategory: map[string][]Page{},
+		PagesByTag:      map[string][]Page{},
+		Tags:            collectTags(pages),
+		Authors:         collectAuthors(pages),
+		Sections:        sections,
+		Menu:            menu,
+		TopNav:          topNav,
+	}
+
+	for _, currentPage := range pages {
+		if currentPage.Kind != "article" {
+			continue
+		}
+		site.Pages = append(site.Pages, currentPage)
+		if currentPage.Date != "" && !parseDate(currentPage.Date).IsZero() {
+			site.Recent = append(site.Recent, currentPage)
+		}
+		if currentPage.Dir != "" {
+			section := strings.Split(currentPage.Dir, "/")[0]
+			site.PagesBySection[section] = append(site.PagesBySection[section], currentPage)
+			site.PagesByCategory[currentPage.Dir] = append(site.PagesByCategory[currentPage.Dir], currentPage)
+		}
+	}
+
+	sort.SliceStable(site.Recent, func(i, j int) bool {
+		return parseDate(site.Recent[i].Date).After(parseDate(site.Recent[j].Date))
+	})
+	for dir, categoryPages := range site.PagesByCategory {
+		sortPages(categoryPages, sortOrder(dir))
+	}
+	for _, tag := range site.Tags {
+		site.PagesByTag[tag.Slug] = tag.Pages
+	}
+
+	return site
+}
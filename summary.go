@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Markdown before this is the page's summary
+const summaryDivider = "<!--more-->"
+
+// Words in a summary when summarylength isn't set in config.yaml
+const defaultSummaryLength = 70
+
+// Characters of the summary used for the meta description when a page doesn't have one
+const descriptionLength = 160
+
+// A page's summary: the `summary` front matter, everything before <!--more-->, or the first summarylength words
+func pageSummary(markdown []byte, content string, frontMatter map[string]interface{}) template.HTML {
+	if frontMatterSummary := metaString(frontMatter, "summary"); frontMatterSummary != "" {
+		return markdownify(frontMatterSummary)
+	}
+	if dividerSummary, ok := markdownBeforeDivider(markdown); ok {
+		return dividerSummary
+	}
+
+	length := defaultSummaryLength
+	if siteMeta.SummaryLength > 0 {
+		length = siteMeta.SummaryLength
+	}
+	return template.HTML(template.HTMLEscapeString(summary(length, template.HTML(content))))
+}
+
+// The markdown before <!--more--> rendered on its own so every tag is closed, even when the divider is in the
+// middle of a paragraph. Headings don't get ids since summaries are shown on listing pages
+func markdownBeforeDivider(markdown []byte) (template.HTML, bool) {
+	renderer := newMarkdown()
+	doc := renderer.Parser().Parse(text.NewReader(markdown))
+
+	divider := findDivider(doc, markdown)
+	if divider == nil {
+		return "", false
+	}
+	for node := divider; node.Parent() != nil; node = node.Parent() {
+		for next := node.NextSibling(); next != nil; next = node.NextSibling() {
+			node.Parent().RemoveChild(node.Parent(), next)
+		}
+	}
+	if before, ok := divider.PreviousSibling().(*ast.Text); ok {
+		before.Segment = before.Segment.TrimRightSpace(markdown)
+	}
+	divider.Parent().RemoveChild(divider.Parent(), divider)
+
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, ok := node.(*ast.Heading); ok && entering {
+			attributes := node.Attributes()
+			node.RemoveAttributes()
+			for _, attribute := range attributes {
+				if string(attribute.Name) != "id" {
+					node.SetAttribute(attribute.Name, attribute.Value)
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var buf bytes.Buffer
+	if err := renderer.Renderer().Render(&buf, markdown, doc); err != nil {
+		log.Print("ERROR: ", err, " in markdownBeforeDivider([]byte)")
+		return "", false
+	}
+	return template.HTML(strings.TrimSpace(buf.String())), true
+}
+
+// The first HTML block or inline HTML that is <!--more-->, dividers in code blocks don't count
+func findDivider(doc ast.Node, markdown []byte) ast.Node {
+	var divider ast.Node
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || divider != nil {
+			return ast.WalkContinue, nil
+		}
+
+		var segments *text.Segments
+		switch html := node.(type) {
+		case *ast.HTMLBlock:
+			segments = html.Lines()
+		case *ast.RawHTML:
+			segments = html.Segments
+		default:
+			return ast.WalkContinue, nil
+		}
+		for i := 0; i < segments.Len(); i++ {
+			segment := segments.At(i)
+			if strings.Contains(string(segment.Value(markdown)), summaryDivider) {
+				divider = node
+				return ast.WalkStop, nil
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return divider
+}
+
+func wordCount(content string) int {
+	return len(strings.Fields(plainText(content)))
+}
+
+// Minutes it takes to read a number of words, never less than 1
+func readingMinutes(words int) int {
+	return int(math.Max(1, math.Ceil(float64(words)/readingSpeed)))
+}
+
+// The summary as plain text short enough for a meta description
+func summaryDescription(pageSummary template.HTML) string {
+	return truncate(descriptionLength, strings.Join(strings.Fields(plainText(string(pageSummary))), " "))
+}
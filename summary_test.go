@@ -0,0 +1,96 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestPageSummary(t *testing.T) {
+	savedMeta := siteMeta
+	defer func() { siteMeta = savedMeta }()
+	siteMeta.SummaryLength = 4
+
+	tests := []struct {
+		name           string
+		headingAnchors bool
+		markdown       string
+		want           template.HTML
+	}{
+		{"first words", false, "One two *three* four five", "One two three four…"},
+		{"short page", false, "One two", "One two"},
+		{"escaped", false, "a &lt;b&gt; c", "a &lt;b&gt; c"},
+		{"front matter", false, "---\nsummary: Short *one*\n---\nOne two three four five", "Short <em>one</em>"},
+		{"front matter before the divider", false, "---\nsummary: Short\n---\nFirst\n\n<!--more-->\n\nRest", "Short"},
+		{"divider", false, "First\n\n<!--more-->\n\nRest", "<p>First</p>"},
+		{"divider in a paragraph", false, "## Heading 3\n\nText <!--more--> more\n\nRest", "<h2>Heading 3</h2>\n<p>Text</p>"},
+		{"divider in a list", false, "- one\n- two <!--more--> three\n- four", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>"},
+		{"divider in a code block", false, "```\n<!--more-->\n```\n\nOne two three four five", "&lt;!--more--&gt; One two three…"},
+		{"heading ids before the divider", true, "## Intro {#intro .lead}\n\nFirst\n\n<!--more-->", "<h2 class=\"lead\">Intro</h2>\n<p>First</p>"},
+		{"heading anchor", true, "## Intro\n\nOne two three four five", "Intro One two three…"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			siteMeta.HeadingAnchors = test.headingAnchors
+			content, frontMatter, _ := renderMarkdown([]byte(test.markdown))
+			if got := pageSummary([]byte(test.markdown), content, frontMatter); got != test.want {
+				t.Errorf("pageSummary(%q) = %q, want %q", test.markdown, got, test.want)
+			}
+		})
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"", 0},
+		{"<p>One two</p>\n<p>three</p>", 3},
+		{"<h2>Title</h2><ul><li>a</li><li>b</li></ul>", 3},
+		{"<h2 id=\"title\">Title <a class=\"anchor\" href=\"#title\">#</a></h2>", 1},
+	}
+
+	for _, test := range tests {
+		if got := wordCount(test.content); got != test.want {
+			t.Errorf("wordCount(%q) = %d, want %d", test.content, got, test.want)
+		}
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		words int
+		want  int
+	}{
+		{0, 1},
+		{readingSpeed, 1},
+		{readingSpeed + 1, 2},
+		{readingSpeed * 5, 5},
+	}
+
+	for _, test := range tests {
+		if got := readingMinutes(test.words); got != test.want {
+			t.Errorf("readingMinutes(%d) = %d, want %d", test.words, got, test.want)
+		}
+	}
+}
+
+func TestSummaryDescription(t *testing.T) {
+	long := strings.Repeat("word ", descriptionLength)
+
+	tests := []struct {
+		summary template.HTML
+		want    string
+	}{
+		{"<p>A short\nsummary</p>", "A short summary"},
+		{template.HTML("<p>" + long + "</p>"), strings.TrimSpace(strings.Repeat("word ", descriptionLength/5)) + "…"},
+	}
+
+	for _, test := range tests {
+		if got := summaryDescription(test.summary); got != test.want {
+			t.Errorf("summaryDescription(%q) = %q, want %q", test.summary, got, test.want)
+		}
+	}
+}
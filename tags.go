@@ -0,0 +1,148 @@
+package main
+
+import (
+	"html/template"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Every tag on a page, tags are a comma separated list in front matter
+func pageTags(currentPage Page) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(currentPage.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// Lower case, with anything that isn't a letter or number turned into a single dash
+func slugify(text string) string {
+	var slugRe = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
+
+// A tag and the articles that use it
+type Tag struct {
+	Name  string
+	Slug  string
+	Url   template.URL
+	Pages []Page
+}
+
+// Every tag used by an article sorted by name, each with its articles newest first
+// Tags that only differ by case or punctuation are the same tag, the first spelling found is used
+// Tags without a letter or number are skipped since they'd have an empty slug
+func collectTags(pages []Page) []Tag {
+	var tags []Tag
+	positions := map[string]int{}
+
+	for _, currentPage := range pages {
+		if currentPage.Kind != "article" {
+			continue
+		}
+		for _, tag := range pageTags(currentPage) {
+			slug := slugify(tag)
+			if slug == "" {
+				log.Print("WARNING: tag `", tag, "` in ", pageOrigin(currentPage), " has no letters or numbers for its URL, skipping it")
+				continue
+			}
+			if _, ok := positions[slug]; !ok {
+				positions[slug] = len(tags)
+				tags = append(tags, Tag{Name: tag, Slug: slug})
+				if siteMeta.TagPages {
+					tags[len(tags)-1].Url = template.URL(siteMeta.BaseURL + "/tags/" + slug + "/")
+				}
+			}
+			tags[positions[slug]].Pages = append(tags[positions[slug]].Pages, currentPage)
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+	for _, tag := range tags {
+		sortPages(tag.Pages, "date")
+	}
+
+	return tags
+}
+
+// With `tagpages` set, a paginated listing page for every tag used by an article, plus a /tags/ page listing the tags
+func buildTagPages(pages []Page) []Page {
+	var tagPages []Page
+	tags := collectTags(pages)
+	if !siteMeta.TagPages || len(tags) == 0 {
+		return nil
+	}
+
+	tagsUrl := siteMeta.BaseURL + "/tags/"
+	var tagsHtml strings.Builder
+	tagsHtml.WriteString("<ul>\n")
+
+	for _, tag := range tags {
+		tagUrl := string(tag.Url)
+		tagsHtml.WriteString("<li><a href=\"" + tagUrl + "\">" + tag.Name + "</a> (" + strconv.Itoa(len(tag.Pages)) + ")</li>\n")
+
+		crumbs := []Breadcrumb{
+			{Title: translate("home"), Url: template.URL(siteMeta.BaseURL)},
+			{Title: translate("tags"), Url: template.URL(tagsUrl)},
+			{Title: tag.Name, Url: tag.Url},
+		}
+		tagPage := Page{
+			Title:       tag.Name,
+			Kind:        "tag",
+			Path:        sitePaths.Output + "/tags/" + tag.Slug + "/index.html",
+			SiteRoot:    template.URL(siteMeta.BaseURL),
+			Nav:         template.HTML(renderBreadcrumbs(crumbs)),
+			Breadcrumbs: crumbs,
+			Analytics:   siteMeta.Analytics,
+			Description: template.HTML(translate("tagdescription", tag.Name)),
+			OgType:      "website",
+			Url:         tag.Url,
+			OgImage:     mediaUrl(siteMeta.OgImage),
+			ChangeFreq:  "weekly",
+			Priority:    "0.5",
+		}
+
+		tagPages = append(tagPages, paginatedPages(tagPage, tag.Pages, func(pager *Paginator) template.HTML {
+			var listingHtml strings.Builder
+			listingHtml.WriteString("<ul>\n")
+			for _, currentPage := range pager.Items {
+				listingHtml.WriteString("<li><a href=\"" + string(currentPage.Url) + "\">" + currentPage.Title + "</a></li>\n")
+			}
+			listingHtml.WriteString("</ul>\n")
+			return template.HTML(listingHtml.String())
+		})...)
+	}
+	tagsHtml.WriteString("</ul>\n")
+
+	crumbs := []Breadcrumb{
+		{Title: translate("home"), Url: template.URL(siteMeta.BaseURL)},
+		{Title: translate("tags"), Url: template.URL(tagsUrl)},
+	}
+	tagPages = append(tagPages, Page{
+		Title:       translate("tags"),
+		Kind:        "tags",
+		Content:     template.HTML(tagsHtml.String()),
+		Path:        sitePaths.Output + "/tags/index.html",
+		SiteRoot:    template.URL(siteMeta.BaseURL),
+		Nav:         template.HTML(renderBreadcrumbs(crumbs)),
+		Breadcrumbs: crumbs,
+		Analytics:   siteMeta.Analytics,
+		Description: template.HTML(translate("tagsdescription")),
+		OgType:      "website",
+		Url:         template.URL(tagsUrl),
+		OgImage:     mediaUrl(siteMeta.OgImage),
+		ChangeFreq:  "weekly",
+		Priority:    "0.5",
+	})
+
+	return tagPages
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Getting Started", "getting-started"},
+		{"  C++ & Go!  ", "c-go"},
+		{"Übersicht", "übersicht"},
+		{"2024", "2024"},
+		{"!!!", ""},
+	}
+
+	for _, test := range tests {
+		if got := slugify(test.text); got != test.want {
+			t.Errorf("slugify(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestCollectTags(t *testing.T) {
+	savedMeta := siteMeta
+	defer func() { siteMeta = savedMeta }()
+	siteMeta = Config{BaseURL: "https://example.com", TagPages: true}
+
+	pages := []Page{
+		{Title: "A", Kind: "article", Tags: "Go, homelab, !!!"},
+		{Title: "B", Kind: "article", Tags: "go,  ,Docker"},
+		{Title: "Notes", Kind: "section", Tags: "listing"},
+	}
+
+	var got [][]string
+	for _, tag := range collectTags(pages) {
+		var titles []string
+		for _, currentPage := range tag.Pages {
+			titles = append(titles, currentPage.Title)
+		}
+		got = append(got, append([]string{tag.Name, tag.Slug, string(tag.Url)}, titles...))
+	}
+
+	want := [][]string{
+		{"Docker", "docker", "https://example.com/tags/docker/", "B"},
+		{"Go", "go", "https://example.com/tags/go/", "A", "B"},
+		{"homelab", "homelab", "https://example.com/tags/homelab/", "A"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectTags() = %q, want %q", got, want)
+	}
+}
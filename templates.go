@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A template directory (templates/<name>/) or the built in default, holding base/ and assets/
+type Theme struct {
+	Name  string
+	Files fs.FS
+}
+
+// Directories in a theme whose *.html files are all parsed
+var templateDirectories = []string{"base", "partials", "shortcodes"}
+
+// Templates in content/.config/templates/ override the theme's, laid out the same way (base/, partials/, shortcodes/)
+var templateOverrideDirectory string = "./content/.config/templates"
+
+// Themes in lookup order: content overrides, the site's template, its parents, and the built in default last
+var siteThemes []Theme
+
+// Parsed once and used for every page
+var siteTemplates *template.Template
+
+// Themes that supply at least one base/ or partials/ template the site uses, shortcode templates don't count
+var siteThemesInUse = map[string]bool{}
+
+// Optional templates/<name>/theme.yaml
+type ThemeConfig struct {
+	Parent string `yaml:"parent"`
+}
+
+// The starter template from `init` is built in and used for anything the site's template doesn't have
+func defaultTheme() Theme {
+	files, err := fs.Sub(starterFiles, "starter/templates/default")
+	if err != nil {
+		log.Fatal("FATAL: ", err, " Could not open the built in template in defaultTheme()")
+	}
+	return Theme{Name: "built in default", Files: files}
+}
+
+// The site's template followed by its parent, the parent's parent and so on
+func themeChain(name string) []Theme {
+	var chain []Theme
+	seen := map[string]bool{}
+
+	for name != "" {
+		if seen[name] {
+			log.Print("ERROR: the parent templates loop back around to ", name, ", ignoring it the second time")
+			break
+		}
+		seen[name] = true
+
+		themeDirectory := sitePaths.CurrentDirectory + "/templates/" + name
+		if info, err := os.Stat(themeDirectory); err != nil || !info.IsDir() {
+			log.Print("ERROR: template directory ", themeDirectory, " doesn't exist, using the built in default template in its place")
+			break
+		}
+		theme := Theme{Name: name, Files: os.DirFS(themeDirectory)}
+		chain = append(chain, theme)
+
+		themeConfig := ThemeConfig{}
+		if content, err := fs.ReadFile(theme.Files, "theme.yaml"); err == nil {
+			if err := yaml.Unmarshal(content, &themeConfig); err != nil {
+				log.Print("ERROR: ", err, " Could not read theme.yaml for the ", name, " template")
+			}
+		}
+		name = themeConfig.Parent
+	}
+
+	return chain
+}
+
+// Work out the theme lookup chain and parse every template from it, a file in a theme replaces the file
+// with the same path in the themes after it and {{define}}s in earlier themes replace the later ones
+func loadTemplates() {
+	siteThemes = nil
+
+	if info, err := os.Stat(templateOverrideDirectory); err == nil && info.IsDir() {
+		siteThemes = append(siteThemes, Theme{Name: "content overrides", Files: os.DirFS(templateOverrideDirectory)})
+	}
+	if siteMeta.TemplateName == "" {
+		log.Println("No templatename in config.yaml, using the built in default template")
+	}
+	siteThemes = append(siteThemes, themeChain(siteMeta.TemplateName)...)
+	siteThemes = append(siteThemes, defaultTheme())
+
+	siteTemplates = template.New("").Funcs(templateFuncs())
+	siteThemesInUse = map[string]bool{}
+	parsed := map[string]bool{}
+
+	for i := len(siteThemes) - 1; i >= 0; i-- {
+		for _, name := range themeTemplates(siteThemes[i]) {
+			if winner, _ := themeFile(name); winner.Name != siteThemes[i].Name || parsed[name] {
+				continue
+			}
+			parsed[name] = true
+			if !strings.HasPrefix(name, "shortcodes/") {
+				siteThemesInUse[siteThemes[i].Name] = true
+			}
+
+			content, err := fs.ReadFile(siteThemes[i].Files, name)
+			if err != nil {
+				log.Fatal("FATAL: ", err, " Could not read ", name, " from the ", siteThemes[i].Name, " template in loadTemplates()")
+			}
+			if _, err := siteTemplates.New(name).Parse(string(content)); err != nil {
+				log.Fatal("FATAL: ", err, " Could not parse ", name, " from the ", siteThemes[i].Name, " template in loadTemplates()")
+			}
+		}
+	}
+
+	// Themes written before templates were named by their path use {{template "header.html" .}}, so the files
+	// directly in base/ can still be used by their file name
+	for _, parsedTemplate := range siteTemplates.Templates() {
+		alias := path.Base(parsedTemplate.Name())
+		if path.Dir(parsedTemplate.Name()) != "base" || parsedTemplate.Tree == nil || siteTemplates.Lookup(alias) != nil {
+			continue
+		}
+		if _, err := siteTemplates.AddParseTree(alias, parsedTemplate.Tree.Copy()); err != nil {
+			log.Fatal("FATAL: ", err, " Could not add ", parsedTemplate.Name(), " as ", alias, " in loadTemplates()")
+		}
+	}
+
+	if siteTemplates.Lookup("Base") == nil {
+		log.Fatal("FATAL: no template defines \"Base\" in loadTemplates()")
+	}
+}
+
+// Every *.html file under a theme's base/, partials/ and shortcodes/ directories
+func themeTemplates(theme Theme) []string {
+	var names []string
+
+	for _, dir := range templateDirectories {
+		if _, err := fs.Stat(theme.Files, dir); err != nil {
+			continue
+		}
+		fs.WalkDir(theme.Files, dir, func(currentFile string, info fs.DirEntry, walkErr error) error {
+			if walkErr == nil && !info.IsDir() && path.Ext(currentFile) == ".html" {
+				names = append(names, currentFile)
+			}
+			return nil
+		})
+	}
+
+	return names
+}
+
+// Find a file in the first theme that has it, content is nil if none do
+func themeFile(name string) (Theme, []byte) {
+	for _, theme := range siteThemes {
+		if content, err := fs.ReadFile(theme.Files, name); err == nil {
+			return theme, content
+		}
+	}
+	return Theme{}, nil
+}
+
+// Copy every theme's assets into out/assets, starting with the built in default so the site's template wins
+// The built in default's assets are only copied when the site uses one of its page templates
+// With `fingerprint` set CSS and JS files are also written with a hash of their content in the name (see asset())
+func copyAssets() {
+	for i := len(siteThemes) - 1; i >= 0; i-- {
+		theme := siteThemes[i]
+		if _, err := fs.Stat(theme.Files, "assets"); err != nil {
+			continue
+		}
+		if i == len(siteThemes)-1 && !siteThemesInUse[theme.Name] {
+			continue
+		}
+
+		err := fs.WalkDir(theme.Files, "assets", func(currentFile string, info fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			assetPath := filepath.Join(sitePaths.Output, currentFile)
+
+			if info.IsDir() {
+				createDirectory(assetPath)
+				return nil
+			}
+
+			if !claimOutput(assetPath, currentFile) {
+				return nil
+			}
+			content, err := fs.ReadFile(theme.Files, currentFile)
+			if err != nil {
+				return err
+			}
+			if siteMeta.Fingerprint && (path.Ext(currentFile) == ".css" || path.Ext(currentFile) == ".js") {
+				fingerprinted := fingerprint(strings.TrimPrefix(currentFile, "assets/"), content)
+				siteAssets[strings.TrimPrefix(currentFile, "assets/")] = fingerprinted
+				if err := os.WriteFile(filepath.Join(sitePaths.Output, "assets", fingerprinted), content, 0644); err != nil {
+					return err
+				}
+			}
+			return os.WriteFile(assetPath, content, 0644)
+		})
+		if err != nil {
+			log.Fatal("FATAL: ", err, " Could not copy assets from the ", theme.Name, " template in copyAssets()")
+		}
+	}
+}
+
+// css/style.css becomes css/style.1a2b3c4d.css
+func fingerprint(assetPath string, content []byte) string {
+	hash := sha256.Sum256(content)
+	ext := path.Ext(assetPath)
+	return strings.TrimSuffix(assetPath, ext) + "." + hex.EncodeToString(hash[:4]) + ext
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateFileNames(t *testing.T) {
+	savedMeta, savedPaths := siteMeta, sitePaths
+	defer func() { siteMeta, sitePaths, siteTemplates = savedMeta, savedPaths, nil }()
+
+	sitePaths.CurrentDirectory = t.TempDir()
+	files := map[string]string{
+		"old/base/base.html":         `{{define "Base"}}{{template "header.html" .}}|{{template "base/header.html" .}}{{end}}`,
+		"old/base/header.html":       `<h1>{{.}}</h1>`,
+		"old/base/nested/nav.html":   `nav`,
+		"defined/base/base.html":     `{{define "Base"}}{{template "header.html" .}}{{end}}{{define "header.html"}}defined{{end}}`,
+		"defined/base/header.html":   `file`,
+		"override/theme.yaml":        "parent: old\n",
+		"override/base/header.html":  `<h2>{{.}}</h2>`,
+		"override/partials/nav.html": `partial`,
+	}
+	for name, content := range files {
+		file := filepath.Join(sitePaths.CurrentDirectory, "templates", name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		theme       string
+		want        string
+		wantMissing []string
+	}{
+		{"old", "<h1>a &amp; b</h1>|<h1>a &amp; b</h1>", []string{"nav.html", "nested/nav.html"}},
+		{"defined", "defined", nil},
+		{"override", "<h2>a &amp; b</h2>|<h2>a &amp; b</h2>", []string{"nav.html"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.theme, func(t *testing.T) {
+			siteMeta.TemplateName = test.theme
+			loadTemplates()
+
+			var rendered bytes.Buffer
+			if err := siteTemplates.ExecuteTemplate(&rendered, "Base", "a & b"); err != nil {
+				t.Fatal(err)
+			}
+			if rendered.String() != test.want {
+				t.Errorf("Base = %q, want %q", rendered.String(), test.want)
+			}
+			for _, name := range test.wantMissing {
+				if siteTemplates.Lookup(name) != nil {
+					t.Errorf("%s is defined, want it only by its path", name)
+				}
+			}
+		})
+	}
+}
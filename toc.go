@@ -0,0 +1,120 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// One heading in a page's table of contents, headings below it are its Children
+type TocEntry struct {
+	ID       string
+	Title    string
+	Level    int // 2 for <h2> and so on
+	Children []TocEntry
+}
+
+// Defaults for tocminlevel, tocmaxlevel and tocthreshold in config.yaml
+// H1 is the page title in most templates so the table of contents starts at H2
+const (
+	defaultTocMinLevel  = 2
+	defaultTocMaxLevel  = 6
+	defaultTocThreshold = 3
+)
+
+// The headings of a rendered markdown document between tocminlevel and tocmaxlevel, nested by level
+func tocEntries(doc ast.Node, source []byte) []TocEntry {
+	var headings []TocEntry
+	minLevel, maxLevel := tocLevels()
+
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level < minLevel || heading.Level > maxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+
+		if id, ok := heading.AttributeString("id"); ok {
+			if idBytes, ok := id.([]byte); ok {
+				headings = append(headings, TocEntry{ID: string(idBytes), Title: string(heading.Text(source)), Level: heading.Level})
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	return nestToc(headings)
+}
+
+// Put each heading under the heading before it with a lower level
+func nestToc(headings []TocEntry) []TocEntry {
+	var entries []TocEntry
+
+	for i := 0; i < len(headings); {
+		entry := headings[i]
+		next := i + 1
+		for next < len(headings) && headings[next].Level > entry.Level {
+			next++
+		}
+		entry.Children = nestToc(headings[i+1 : next])
+		entries = append(entries, entry)
+		i = next
+	}
+
+	return entries
+}
+
+func tocLevels() (int, int) {
+	minLevel, maxLevel := defaultTocMinLevel, defaultTocMaxLevel
+	if siteMeta.TocMinLevel > 0 {
+		minLevel = siteMeta.TocMinLevel
+	}
+	if siteMeta.TocMaxLevel > 0 {
+		maxLevel = siteMeta.TocMaxLevel
+	}
+	return minLevel, maxLevel
+}
+
+// Front matter `toc: false` turns the table of contents off for a page
+func tocEnabled(frontMatter map[string]interface{}) bool {
+	enabled, ok := frontMatter["toc"].(bool)
+	return !ok || enabled
+}
+
+// The table of contents as nested lists, or nothing if there are fewer than tocthreshold headings
+func renderToc(entries []TocEntry) template.HTML {
+	threshold := defaultTocThreshold
+	if siteMeta.TocThreshold > 0 {
+		threshold = siteMeta.TocThreshold
+	}
+	if tocCount(entries) < threshold {
+		return ""
+	}
+
+	var toc strings.Builder
+	writeToc(&toc, entries)
+	return template.HTML(toc.String())
+}
+
+func writeToc(toc *strings.Builder, entries []TocEntry) {
+	toc.WriteString("<ul>\n")
+	for _, entry := range entries {
+		toc.WriteString("<li><a href=\"#" + template.HTMLEscapeString(entry.ID) + "\">" + template.HTMLEscapeString(entry.Title) + "</a>")
+		if len(entry.Children) > 0 {
+			toc.WriteString("\n")
+			writeToc(toc, entry.Children)
+		}
+		toc.WriteString("</li>\n")
+	}
+	toc.WriteString("</ul>\n")
+}
+
+func tocCount(entries []TocEntry) int {
+	count := len(entries)
+	for _, entry := range entries {
+		count += tocCount(entry.Children)
+	}
+	return count
+}